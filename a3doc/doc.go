@@ -0,0 +1,210 @@
+// Package a3doc renders the manifest of an extension, written by a3interface.WriteManifest, to Markdown for mission makers: an index of the commands, then the arguments, response, callbacks, access policy and an example of each
+package a3doc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/indig0fox/a3go/a3interface"
+)
+
+// ReadManifest reads a manifest written by a3interface.WriteManifest
+func ReadManifest(r io.Reader) (a3interface.ExtensionManifest, error) {
+	var manifest a3interface.ExtensionManifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return a3interface.ExtensionManifest{}, fmt.Errorf("error parsing manifest: %s", err.Error())
+	}
+	return manifest, nil
+}
+
+// ReadManifestFile reads a manifest from a file, see ReadManifest
+func ReadManifestFile(name string) (a3interface.ExtensionManifest, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return a3interface.ExtensionManifest{}, fmt.Errorf("error reading manifest: %s", err.Error())
+	}
+	defer file.Close()
+	return ReadManifest(file)
+}
+
+// WriteMarkdown writes the documentation of every command in manifest
+func WriteMarkdown(w io.Writer, manifest a3interface.ExtensionManifest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", manifest.Extension)
+	if manifest.Version != "" {
+		fmt.Fprintf(&b, "Version %s\n\n", manifest.Version)
+	}
+	fmt.Fprintf(&b, "Commands are called with `%q callExtension [command, [arguments]]`. Failed calls return `[\"error\", code, message, details, retryable]`.\n\n", manifest.Extension)
+
+	b.WriteString("| Command | Mode | Description |\n|---|---|---|\n")
+	for _, command := range manifest.Commands {
+		fmt.Fprintf(&b, "| [%s](#%s) | %s | %s |\n", code(command.Command), anchor(command.Command), command.Mode, cell(command.Description))
+	}
+	for _, command := range manifest.Commands {
+		b.WriteString("\n")
+		writeCommand(&b, command)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeCommand writes the section of a command
+func writeCommand(b *strings.Builder, command a3interface.CommandManifest) {
+	fmt.Fprintf(b, "## %s\n\n", code(command.Command))
+	if command.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", command.Description)
+	}
+	if command.Mode == "async" {
+		fmt.Fprintf(b, "- **Mode:** async, Arma receives %s and the command runs in the background\n", code(command.DefaultResponse))
+	} else {
+		b.WriteString("- **Mode:** sync, Arma waits for the response\n")
+	}
+	if len(command.Aliases) > 0 {
+		aliases := make([]string, 0, len(command.Aliases))
+		for _, alias := range command.Aliases {
+			aliases = append(aliases, code(alias))
+		}
+		fmt.Fprintf(b, "- **Aliases:** %s\n", strings.Join(aliases, ", "))
+	}
+	if command.TimeBudget != "" {
+		fmt.Fprintf(b, "- **Time budget:** %s, slower calls return `[\"pending\", jobID]` and the response is sent to the %s callback\n", command.TimeBudget, code(a3interface.PendingCallbackFunction))
+	}
+
+	b.WriteString("\n### Arguments\n\n")
+	switch {
+	case command.Args == nil:
+		b.WriteString("Not checked.\n")
+	case len(command.Args.Args) == 0 && !command.Args.Variadic:
+		b.WriteString("None.\n")
+	default:
+		if len(command.Args.Args) > 0 {
+			b.WriteString("| # | Name | Type | Required | Range |\n|---|---|---|---|---|\n")
+			for index, arg := range command.Args.Args {
+				required := "yes"
+				if arg.Optional {
+					required = "no"
+				}
+				fmt.Fprintf(b, "| %d | %s | %s | %s | %s |\n", index, cell(arg.Name), arg.Type, required, argRange(arg))
+			}
+		}
+		if command.Args.Variadic {
+			if len(command.Args.Args) > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("Further arguments are accepted and not checked.\n")
+		}
+	}
+
+	b.WriteString("\n### Response\n\n")
+	response := "Not documented."
+	if command.Response != "" {
+		response = code(command.Response)
+	}
+	if command.Mode == "async" {
+		response = code(command.DefaultResponse) + ", the results are sent by callback."
+	}
+	if command.ResponseEnvelope {
+		response += " Wrapped as `[\"ok\", response]`."
+	}
+	fmt.Fprintf(b, "%s\n", response)
+
+	if len(command.Callbacks) > 0 {
+		b.WriteString("\n### Callbacks\n\n| Function | Data |\n|---|---|\n")
+		for _, callback := range command.Callbacks {
+			fmt.Fprintf(b, "| %s | %s |\n", code(callback.Function), cell(callback.Description))
+		}
+	}
+
+	if len(command.Access) > 0 {
+		b.WriteString("\n### Access\n\nEvery condition must be met:\n\n")
+		for _, policy := range command.Access {
+			for _, condition := range conditions(policy) {
+				fmt.Fprintf(b, "- %s\n", condition)
+			}
+		}
+	}
+
+	if command.Example != "" {
+		fmt.Fprintf(b, "\n### Example\n\n```sqf\n%s\n```\n", command.Example)
+	}
+}
+
+// conditions describes the conditions of an access policy
+func conditions(policy *a3interface.AccessPolicy) []string {
+	var conditions []string
+	if policy.ServerOnly {
+		conditions = append(conditions, "called by the server itself")
+	}
+	if policy.DenyRemoteExecuted {
+		conditions = append(conditions, "not remote executed by a client")
+	}
+	switch {
+	case len(policy.SteamIDs) > 0 && policy.AdminsFile != "":
+		conditions = append(conditions, fmt.Sprintf("called by %s or an admin listed in %s", codeList(policy.SteamIDs), code(policy.AdminsFile)))
+	case len(policy.SteamIDs) > 0:
+		conditions = append(conditions, fmt.Sprintf("called by %s", codeList(policy.SteamIDs)))
+	case policy.AdminsFile != "":
+		conditions = append(conditions, fmt.Sprintf("called by an admin listed in %s", code(policy.AdminsFile)))
+	}
+	if len(policy.Missions) > 0 {
+		conditions = append(conditions, fmt.Sprintf("in a mission matching %s", codeList(policy.Missions)))
+	}
+	return conditions
+}
+
+// argRange describes the range of an argument, in length for anything but numbers
+func argRange(arg a3interface.ArgSpec) string {
+	unit := " long"
+	if arg.Type == a3interface.ArgNumber {
+		unit = ""
+	}
+	switch {
+	case arg.Min != nil && arg.Max != nil:
+		return fmt.Sprintf("%v to %v%s", *arg.Min, *arg.Max, unit)
+	case arg.Min != nil:
+		return fmt.Sprintf("at least %v%s", *arg.Min, unit)
+	case arg.Max != nil:
+		return fmt.Sprintf("at most %v%s", *arg.Max, unit)
+	default:
+		return ""
+	}
+}
+
+// code formats s as inline code, which may contain backticks
+func code(s string) string {
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+// codeList formats values as a list of inline code
+func codeList(values []string) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, code(value))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// cell escapes s for a table cell
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(s)
+}
+
+// anchor returns the link fragment GitHub gives the heading of a command: lower case, without punctuation other than - and _
+func anchor(command string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(command) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
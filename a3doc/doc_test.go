@@ -0,0 +1,171 @@
+package a3doc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const manifestJSON = `{
+  "extension": "MyExtension",
+  "version": "1.2.3",
+  "commands": [
+    {
+      "command": "admin:kick",
+      "aliases": ["admin:remove"],
+      "mode": "sync",
+      "responseEnvelope": true,
+      "access": [{"serverOnly": true}, {"adminsFile": "admins.txt", "missions": ["co10_*"]}]
+    },
+    {
+      "command": "load",
+      "mode": "async",
+      "defaultResponse": "[\"loading\"]",
+      "args": {"args": [], "variadic": true},
+      "callbacks": [{"function": "loaded", "description": "[id, loadout]"}]
+    },
+    {
+      "command": "save",
+      "mode": "sync",
+      "description": "Saves a loadout",
+      "args": {"args": [{"name": "loadout", "type": "array", "min": 1}, {"name": "slot", "type": "number", "optional": true, "min": 1, "max": 5}]},
+      "response": "[saved]",
+      "timeBudget": "50ms",
+      "callbacks": [{"function": "pending", "description": "[jobID, response]"}],
+      "example": "\"MyExtension\" callExtension [\"save\", [[1, 2]]]"
+    }
+  ]
+}`
+
+const wantMarkdown = "# MyExtension\n" +
+	"\n" +
+	"Version 1.2.3\n" +
+	"\n" +
+	"Commands are called with `\"MyExtension\" callExtension [command, [arguments]]`. Failed calls return `[\"error\", code, message, details, retryable]`.\n" +
+	"\n" +
+	"| Command | Mode | Description |\n" +
+	"|---|---|---|\n" +
+	"| [`admin:kick`](#adminkick) | sync |  |\n" +
+	"| [`load`](#load) | async |  |\n" +
+	"| [`save`](#save) | sync | Saves a loadout |\n" +
+	"\n" +
+	"## `admin:kick`\n" +
+	"\n" +
+	"- **Mode:** sync, Arma waits for the response\n" +
+	"- **Aliases:** `admin:remove`\n" +
+	"\n" +
+	"### Arguments\n" +
+	"\n" +
+	"Not checked.\n" +
+	"\n" +
+	"### Response\n" +
+	"\n" +
+	"Not documented. Wrapped as `[\"ok\", response]`.\n" +
+	"\n" +
+	"### Access\n" +
+	"\n" +
+	"Every condition must be met:\n" +
+	"\n" +
+	"- called by the server itself\n" +
+	"- called by an admin listed in `admins.txt`\n" +
+	"- in a mission matching `co10_*`\n" +
+	"\n" +
+	"## `load`\n" +
+	"\n" +
+	"- **Mode:** async, Arma receives `[\"loading\"]` and the command runs in the background\n" +
+	"\n" +
+	"### Arguments\n" +
+	"\n" +
+	"Further arguments are accepted and not checked.\n" +
+	"\n" +
+	"### Response\n" +
+	"\n" +
+	"`[\"loading\"]`, the results are sent by callback.\n" +
+	"\n" +
+	"### Callbacks\n" +
+	"\n" +
+	"| Function | Data |\n" +
+	"|---|---|\n" +
+	"| `loaded` | [id, loadout] |\n" +
+	"\n" +
+	"## `save`\n" +
+	"\n" +
+	"Saves a loadout\n" +
+	"\n" +
+	"- **Mode:** sync, Arma waits for the response\n" +
+	"- **Time budget:** 50ms, slower calls return `[\"pending\", jobID]` and the response is sent to the `pending` callback\n" +
+	"\n" +
+	"### Arguments\n" +
+	"\n" +
+	"| # | Name | Type | Required | Range |\n" +
+	"|---|---|---|---|---|\n" +
+	"| 0 | loadout | array | yes | at least 1 long |\n" +
+	"| 1 | slot | number | no | 1 to 5 |\n" +
+	"\n" +
+	"### Response\n" +
+	"\n" +
+	"`[saved]`\n" +
+	"\n" +
+	"### Callbacks\n" +
+	"\n" +
+	"| Function | Data |\n" +
+	"|---|---|\n" +
+	"| `pending` | [jobID, response] |\n" +
+	"\n" +
+	"### Example\n" +
+	"\n" +
+	"```sqf\n" +
+	"\"MyExtension\" callExtension [\"save\", [[1, 2]]]\n" +
+	"```\n"
+
+func TestWriteMarkdown(t *testing.T) {
+	manifest, err := ReadManifest(strings.NewReader(manifestJSON))
+	if err != nil {
+		t.Fatalf("ReadManifest() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, manifest); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	if got := buf.String(); got != wantMarkdown {
+		t.Errorf("WriteMarkdown() =\n%s\nwant\n%s", got, wantMarkdown)
+	}
+}
+
+func Test_code(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "save", want: "`save`"},
+		{s: "a`b", want: "`` a`b ``"},
+	}
+	for _, tt := range tests {
+		if got := code(tt.s); got != tt.want {
+			t.Errorf("code(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func Test_anchor(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "save", want: "save"},
+		{command: "admin:kick", want: "adminkick"},
+		{command: "Player_Save-All", want: "player_save-all"},
+		{command: "admin:{name}", want: "adminname"},
+	}
+	for _, tt := range tests {
+		if got := anchor(tt.command); got != tt.want {
+			t.Errorf("anchor(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestReadManifest_invalid(t *testing.T) {
+	if _, err := ReadManifest(strings.NewReader("{")); err == nil {
+		t.Errorf("ReadManifest() error = nil, want an error")
+	}
+}
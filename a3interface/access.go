@@ -0,0 +1,277 @@
+package a3interface
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AccessPolicy restricts who can call a command, based on the ArmaExtensionContext of the call. Every condition that is set must be met. It can be set in code with SetAccessPolicy or loaded from a file with LoadAccessPolicies
+type AccessPolicy struct {
+	// ServerOnly allows only calls made by a dedicated server itself: the SteamID is "0" or empty, and the call was not remote executed by a client, so RemoteExecutedOwner is empty, "0" or "2", the ID of the server
+	ServerOnly bool `json:"serverOnly,omitempty"`
+	// SteamIDs are allowed to call the command, together with the admins in AdminsFile. If both are empty, any SteamID is allowed
+	SteamIDs []string `json:"steamIds,omitempty"`
+	// AdminsFile is the path of a file with a SteamID per line that are allowed to call the command. Empty lines and lines starting with # are ignored, and anything after the SteamID on a line is treated as a comment. It is read again by Reload
+	AdminsFile string `json:"adminsFile,omitempty"`
+	// Missions are path.Match patterns, one of which the mission name must match, i.e. "co10_*"
+	Missions []string `json:"missions,omitempty"`
+	// DenyRemoteExecuted denies calls that were remote executed by a client
+	DenyRemoteExecuted bool `json:"denyRemoteExecuted,omitempty"`
+
+	mu     sync.RWMutex
+	admins map[string]bool
+	loaded bool
+}
+
+// Reload reads AdminsFile again. The previous admins are kept if the file can't be read
+func (p *AccessPolicy) Reload() error {
+	if p.AdminsFile == "" {
+		return nil
+	}
+	admins, err := readAdminsFile(p.AdminsFile)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.admins = admins
+	p.loaded = true
+	return nil
+}
+
+// Check returns an ErrCodeAccessDenied error if a call with ctx is not allowed by the policy
+func (p *AccessPolicy) Check(ctx ArmaExtensionContext) error {
+	if p.ServerOnly {
+		if (ctx.SteamID != "" && ctx.SteamID != "0") || (isRemoteExecuted(ctx) && ctx.RemoteExecutedOwner != "2") {
+			return accessDenied("command is server only")
+		}
+	}
+	if p.DenyRemoteExecuted && isRemoteExecuted(ctx) {
+		return accessDenied("command cannot be remote executed")
+	}
+	if len(p.SteamIDs) > 0 || p.AdminsFile != "" {
+		allowed, err := p.allows(ctx.SteamID)
+		if err != nil {
+			return accessDenied("caller is not allowed").Wrap(err)
+		}
+		if !allowed {
+			return accessDenied("caller is not allowed")
+		}
+	}
+	if len(p.Missions) > 0 {
+		matched := false
+		for _, pattern := range p.Missions {
+			if ok, _ := path.Match(pattern, ctx.MissionNameSource); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return accessDenied("command is not allowed in this mission")
+		}
+	}
+	return nil
+}
+
+// allows returns true if steamID is in SteamIDs or the admins file, which is read on first use
+func (p *AccessPolicy) allows(steamID string) (bool, error) {
+	for _, id := range p.SteamIDs {
+		if id == steamID {
+			return true, nil
+		}
+	}
+	if p.AdminsFile == "" {
+		return false, nil
+	}
+	p.mu.RLock()
+	loaded := p.loaded
+	p.mu.RUnlock()
+	if !loaded {
+		if err := p.Reload(); err != nil {
+			return false, err
+		}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.admins[steamID], nil
+}
+
+// isRemoteExecuted returns true if a client remote executed the call
+func isRemoteExecuted(ctx ArmaExtensionContext) bool {
+	return ctx.RemoteExecutedOwner != "" && ctx.RemoteExecutedOwner != "0"
+}
+
+func accessDenied(reason string) *Error {
+	return NewError(ErrCodeAccessDenied, reason)
+}
+
+// readAdminsFile reads a SteamID per line, ignoring empty lines, comments and anything after the SteamID
+func readAdminsFile(name string) (map[string]bool, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening admins file: %s", err.Error())
+	}
+	defer file.Close()
+
+	admins := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		admins[fields[0]] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading admins file: %s", err.Error())
+	}
+	return admins, nil
+}
+
+// accessPolicies holds the policies loaded from a file
+type accessPolicies struct {
+	mu sync.RWMutex
+	// path is the file the policies were loaded from, read again by ReloadAccessPolicies
+	path string
+	// commands are the policies keyed by a path.Match pattern of the commands they apply to
+	commands map[string]*AccessPolicy
+	// patterns are the keys of commands in a stable order
+	patterns []string
+}
+
+// accessPolicyFile is the format of the file read by LoadAccessPolicies
+type accessPolicyFile struct {
+	Commands map[string]*AccessPolicy `json:"commands"`
+}
+
+// LoadAccessPolicies reads access policies from a JSON file, replacing any loaded before. Policies are keyed by a path.Match pattern of the commands they apply to, in addition to any policy set on the registration:
+//
+//	{
+//	  "commands": {
+//	    "saveMyCall": {"serverOnly": true},
+//	    "admin:*": {"adminsFile": "admins.txt", "denyRemoteExecuted": true}
+//	  }
+//	}
+//
+// The policies in use are kept if the file can't be read
+func (e *Extension) LoadAccessPolicies(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("error reading access policies: %s", err.Error())
+	}
+	var file accessPolicyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("error parsing access policies: %s", err.Error())
+	}
+	patterns := make([]string, 0, len(file.Commands))
+	for pattern, policy := range file.Commands {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid command pattern %s: %s", pattern, err.Error())
+		}
+		if policy == nil {
+			return fmt.Errorf("empty access policy for %s", pattern)
+		}
+		if err := policy.Reload(); err != nil {
+			return err
+		}
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	p := e.accessPolicies
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.path = name
+	p.commands = file.Commands
+	p.patterns = patterns
+	return nil
+}
+
+// ReloadAccessPolicies reads the file given to LoadAccessPolicies again, and the admins files of the policies set on registrations
+func (e *Extension) ReloadAccessPolicies() error {
+	p := e.accessPolicies
+	p.mu.RLock()
+	name := p.path
+	p.mu.RUnlock()
+	if name != "" {
+		if err := e.LoadAccessPolicies(name); err != nil {
+			return err
+		}
+	}
+	for _, registration := range e.Registrations() {
+		if registration.AccessPolicy == nil {
+			continue
+		}
+		if err := registration.AccessPolicy.Reload(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAccess checks the policy of the routed registration and the loaded policies that match the command called or the registration's full command and aliases, publishing EventAccessDenied if the call is denied
+func (e *Extension) checkAccess(req *Request, match routeMatch) error {
+	registration := match.registration
+	policies := e.accessPolicies.matching(append(match.names(), req.Command), match.caseInsensitive)
+	if registration.AccessPolicy != nil {
+		policies = append(policies, registration.AccessPolicy)
+	}
+	for _, policy := range policies {
+		err := policy.Check(req.ArmaContext)
+		if err == nil {
+			continue
+		}
+		if cause := errors.Unwrap(err); cause != nil {
+			e.Logger().Error("error reading admins file", "path", policy.AdminsFile, "error", cause.Error())
+		}
+		// the audit record includes the SteamID, which is left out of other logs
+		e.Logger().Warn("access denied",
+			append(req.logAttrs(),
+				slog.String("steam_id", req.ArmaContext.SteamID),
+				slog.String("remote_executed_owner", req.ArmaContext.RemoteExecutedOwner),
+				slog.String("reason", AsError(err).Message),
+			)...,
+		)
+		e.events.publish(Event{
+			Type:    EventAccessDenied,
+			Call:    req.callInfo(registration.RunInBackground),
+			Err:     err,
+			Message: fmt.Sprintf("command %s denied: %s", req.Command, AsError(err).Message),
+		})
+		return err
+	}
+	return nil
+}
+
+// matching returns the loaded policies whose pattern matches any of the names, ignoring case if caseInsensitive is true as the router does, so that a policy can't be bypassed through an alias or a differently cased command
+func (p *accessPolicies) matching(names []string, caseInsensitive bool) []*AccessPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var policies []*AccessPolicy
+	for _, pattern := range p.patterns {
+		for _, name := range names {
+			if matchCommand(pattern, name, caseInsensitive) {
+				policies = append(policies, p.commands[pattern])
+				break
+			}
+		}
+	}
+	return policies
+}
+
+// matchCommand reports whether a command matches a path.Match pattern
+func matchCommand(pattern string, command string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		pattern, command = strings.ToLower(pattern), strings.ToLower(command)
+	}
+	ok, _ := path.Match(pattern, command)
+	return ok
+}
@@ -0,0 +1,148 @@
+package a3interface
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessPolicy_Check(t *testing.T) {
+	admins := filepath.Join(t.TempDir(), "admins.txt")
+	if err := os.WriteFile(admins, []byte("# admins\n76561198000000001 alice\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		policy  *AccessPolicy
+		ctx     ArmaExtensionContext
+		allowed bool
+	}{
+		{name: "server only from server", policy: &AccessPolicy{ServerOnly: true}, ctx: ArmaExtensionContext{SteamID: "0", RemoteExecutedOwner: "0"}, allowed: true},
+		{name: "server only from server remote exec", policy: &AccessPolicy{ServerOnly: true}, ctx: ArmaExtensionContext{SteamID: "0", RemoteExecutedOwner: "2"}, allowed: true},
+		{name: "server only from player", policy: &AccessPolicy{ServerOnly: true}, ctx: ArmaExtensionContext{SteamID: "76561198000000002"}, allowed: false},
+		{name: "server only from client remote exec", policy: &AccessPolicy{ServerOnly: true}, ctx: ArmaExtensionContext{SteamID: "0", RemoteExecutedOwner: "3"}, allowed: false},
+		{name: "allowed SteamID", policy: &AccessPolicy{SteamIDs: []string{"76561198000000002"}}, ctx: ArmaExtensionContext{SteamID: "76561198000000002"}, allowed: true},
+		{name: "other SteamID", policy: &AccessPolicy{SteamIDs: []string{"76561198000000002"}}, ctx: ArmaExtensionContext{SteamID: "76561198000000003"}, allowed: false},
+		{name: "admin from file", policy: &AccessPolicy{AdminsFile: admins}, ctx: ArmaExtensionContext{SteamID: "76561198000000001"}, allowed: true},
+		{name: "not an admin", policy: &AccessPolicy{AdminsFile: admins}, ctx: ArmaExtensionContext{SteamID: "alice"}, allowed: false},
+		{name: "mission matches", policy: &AccessPolicy{Missions: []string{"co10_*"}}, ctx: ArmaExtensionContext{MissionNameSource: "co10_Escape"}, allowed: true},
+		{name: "mission does not match", policy: &AccessPolicy{Missions: []string{"co10_*"}}, ctx: ArmaExtensionContext{MissionNameSource: "tvt20_Hill"}, allowed: false},
+		{name: "remote executed denied", policy: &AccessPolicy{DenyRemoteExecuted: true}, ctx: ArmaExtensionContext{RemoteExecutedOwner: "5"}, allowed: false},
+		{name: "local call allowed", policy: &AccessPolicy{DenyRemoteExecuted: true}, ctx: ArmaExtensionContext{RemoteExecutedOwner: "0"}, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.ctx)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Check() error = %v, want allowed %v", err, tt.allowed)
+			}
+			if err != nil && AsError(err).Code != ErrCodeAccessDenied {
+				t.Errorf("Check() code = %v, want %v", AsError(err).Code, ErrCodeAccessDenied)
+			}
+		})
+	}
+}
+
+func TestLoadAccessPolicies(t *testing.T) {
+	dir := t.TempDir()
+	policies := filepath.Join(dir, "policies.json")
+	write := func(content string) {
+		if err := os.WriteFile(policies, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"commands": {"accessAdmin:*": {"steamIds": ["1"]}}}`)
+	e := NewExtension()
+	if err := e.LoadAccessPolicies(policies); err != nil {
+		t.Fatalf("LoadAccessPolicies() error = %v", err)
+	}
+
+	err := e.Register(NewRegistration("accessAdmin:kick").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(`["kicked"]`)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	subscription := e.Subscribe(4, EventAccessDenied)
+	defer subscription.Unsubscribe()
+
+	e.SetContext(ArmaExtensionContext{SteamID: "2"})
+	want := `["error", "ACCESS_DENIED", "caller is not allowed", [], false]`
+	if got := e.dispatch(CallFormString, "accessAdmin:kick", nil, 10240); got != want {
+		t.Errorf("dispatch() = %v, want %v", got, want)
+	}
+	select {
+	case event := <-subscription.C:
+		if event.Call == nil || event.Call.ArmaContext.SteamID != "2" {
+			t.Errorf("event = %+v, want denied call of SteamID 2", event)
+		}
+	default:
+		t.Error("no access denied event published")
+	}
+
+	write(`{"commands": {"accessAdmin:*": {"steamIds": ["1", "2"]}}}`)
+	if err := e.ReloadAccessPolicies(); err != nil {
+		t.Fatalf("ReloadAccessPolicies() error = %v", err)
+	}
+	if got := e.dispatch(CallFormString, "accessAdmin:kick", nil, 10240); got != `["kicked"]` {
+		t.Errorf("dispatch() after reload = %v, want %v", got, `["kicked"]`)
+	}
+}
+
+func TestLoadAccessPolicies_routedNames(t *testing.T) {
+	policies := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(policies, []byte(`{"commands": {"saveMyCall": {"serverOnly": true}, "admin:kick": {"serverOnly": true}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExtension()
+	e.SetCaseInsensitive(true)
+	if err := e.LoadAccessPolicies(policies); err != nil {
+		t.Fatalf("LoadAccessPolicies() error = %v", err)
+	}
+	handler := HandlerFunc(func(req *Request) error {
+		_, err := req.Writer.WriteString(`["saved"]`)
+		return err
+	})
+	if err := e.Register(NewRegistration("saveMyCall").SetAliases("save").SetHandler(handler)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	admin := NewRouter()
+	if err := NewRegistration("kick").SetAliases("remove").SetHandler(handler).RegisterTo(admin); err != nil {
+		t.Fatalf("RegisterTo() error = %v", err)
+	}
+	if err := e.Mount("admin", admin); err != nil {
+		t.Fatalf("Mount() error = %v", err)
+	}
+	e.SetContext(ArmaExtensionContext{SteamID: "76561198000000000"})
+
+	want := `["error", "ACCESS_DENIED", "command is server only", [], false]`
+	for _, command := range []string{"saveMyCall", "savemycall", "save", "SAVE", "admin:kick", "admin:remove", "ADMIN:Remove"} {
+		if got := e.dispatch(CallFormString, command, nil, 10240); got != want {
+			t.Errorf("dispatch(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+func TestExtension_checkAccess_logsOnce(t *testing.T) {
+	e := NewExtension()
+	var logs bytes.Buffer
+	e.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	err := e.Register(NewRegistration("accessLogged").
+		SetAccessPolicy(&AccessPolicy{ServerOnly: true}).
+		SetHandler(HandlerFunc(func(req *Request) error { return nil })))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	e.SetContext(ArmaExtensionContext{SteamID: "76561198000000000"})
+	e.dispatch(CallFormString, "accessLogged", nil, 10240)
+
+	if got := strings.Count(logs.String(), "\n"); got != 1 || !strings.Contains(logs.String(), "access denied") {
+		t.Errorf("logged %d records, want the access denied record only:\n%s", got, logs.String())
+	}
+}
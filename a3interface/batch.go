@@ -0,0 +1,65 @@
+package a3interface
+
+// RegisterBatchCommand registers a synchronous command that runs several calls in a single callExtension, i.e.
+//
+//	"extension" callExtension ["batch", [[["test", ["a"]], ["saveMyCall", [[1, 2]]]], true]]
+//
+// The first argument is an array of [command, args] tuples, each dispatched as if Arma had called ["command", args], through the router and its middleware. The optional second argument stops the batch at the first call that fails.
+//
+// It returns the response of each call as a string, in order, exactly as callExtension would have returned it, i.e. ["[""a""]", "[""error"", ...]"]. If the batch is stopped, the responses of the calls that were not run are omitted
+func (e *Extension) RegisterBatchCommand(command string) error {
+	return e.Register(NewRegistration(command).
+		SetDescription("Runs several [command, args] calls in one callExtension, optionally stopping at the first that fails.").
+		SetResponse("[response, ...], each as callExtension would have returned it").
+		SetArgSchema(NewArgSchema().
+			Arg("calls", ArgArray).
+			OptionalArg("stopOnError", ArgBool),
+		).
+		SetHandler(HandlerFunc(serveBatch)),
+	)
+}
+
+// serveBatch dispatches every call of a batch request
+func serveBatch(req *Request) error {
+	calls := req.Values[0].([]interface{})
+	stopOnError := len(req.Values) > 1 && req.Values[1].(bool)
+
+	responses := make([]interface{}, 0, len(calls))
+	for index, call := range calls {
+		response := dispatchBatchItem(req, index, call)
+		responses = append(responses, response)
+		if stopOnError && isErrorEnvelope(response) {
+			break
+		}
+	}
+	_, err := req.Writer.WriteString(ToArmaHashMap(responses))
+	return err
+}
+
+// dispatchBatchItem dispatches a single [command, args] tuple of a batch as a call in the ["command", ["data"]] format, with the batch request's context as its parent
+func dispatchBatchItem(batch *Request, index int, call interface{}) string {
+	tuple, ok := call.([]interface{})
+	if !ok || len(tuple) < 1 || len(tuple) > 2 {
+		return Errorf(ErrCodeInvalidArgs, "batch call %d must be [command, args]", index).SQF()
+	}
+	command, ok := tuple[0].(string)
+	if !ok {
+		return Errorf(ErrCodeInvalidArgs, "batch call %d must have a string command", index).SQF()
+	}
+	var args []interface{}
+	if len(tuple) > 1 {
+		if args, ok = tuple[1].([]interface{}); !ok {
+			return Errorf(ErrCodeInvalidArgs, "batch call %d must have an array of args", index).SQF()
+		}
+	}
+
+	// arguments are sent to the handler as Arma would, strings quoted and everything else in its SQF form
+	data := make([]string, len(args))
+	for i, arg := range args {
+		data[i] = ToArmaHashMap(arg)
+	}
+	e := batch.Extension()
+	req, match := e.newRequest(CallFormArgs, command, data, 0)
+	req.ctx = batch.Context()
+	return e.finish(req, e.handle(req, match))
+}
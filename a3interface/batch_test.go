@@ -0,0 +1,50 @@
+package a3interface
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterBatchCommand(t *testing.T) {
+	e := NewExtension()
+	if err := e.RegisterBatchCommand("batch"); err != nil {
+		t.Fatalf("RegisterBatchCommand() error = %v", err)
+	}
+	err := e.Register(NewRegistration("batchEcho").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(ToArmaHashMap([]interface{}{strings.Join(req.Args, ",")}))
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []string
+		want string
+	}{
+		{
+			name: "continue on error",
+			data: []string{`[["batchEcho", ["a", 1, [true]]], ["batchMissing", []], ["batchEcho"]]`},
+			want: `["[""a,1,[true]""]", "[""error"", ""NOT_REGISTERED"", ""command batchMissing not registered"", [], false]", "[""""]"]`,
+		},
+		{
+			name: "stop on error",
+			data: []string{`[["batchMissing", []], ["batchEcho", []]]`, "true"},
+			want: `["[""error"", ""NOT_REGISTERED"", ""command batchMissing not registered"", [], false]"]`,
+		},
+		{
+			name: "invalid tuple",
+			data: []string{`[[1, []]]`},
+			want: `["[""error"", ""INVALID_ARGS"", ""batch call 0 must have a string command"", [], false]"]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.dispatch(CallFormArgs, "batch", tt.data, 10240); got != tt.want {
+				t.Errorf("dispatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package a3interface
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// PendingCallbackFunction is the callback function that receives the results of calls that exceeded their time budget, as [jobID, response]
+const PendingCallbackFunction = "pending"
+
+// budgetContext reports the end of a time budget as its deadline without being cancelled when it passes
+type budgetContext struct {
+	context.Context
+	deadline time.Time
+}
+
+// Deadline returns the end of the time budget, or the parent's deadline if it is earlier
+func (c budgetContext) Deadline() (time.Time, bool) {
+	if deadline, ok := c.Context.Deadline(); ok && deadline.Before(c.deadline) {
+		return deadline, true
+	}
+	return c.deadline, true
+}
+
+// serveWithBudget runs a synchronous handler for at most the registration's time budget. If the handler finishes in time its response is returned, otherwise a pending envelope is returned and the response is sent by callback once the handler finishes
+func (e *Extension) serveWithBudget(handler Handler, req *Request, registration *RVExtensionRegistration, span *Span) string {
+	budget := registration.TimeBudget
+	req.ctx = budgetContext{Context: req.Context(), deadline: time.Now().Add(budget)}
+	buf := &responseBuffer{}
+	req.Writer = buf
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.serve(handler, req, false)
+	}()
+
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		span.SetError(err)
+		span.End()
+		if err != nil {
+			return AsError(err).SQF()
+		}
+		return e.respond(registration, buf.String())
+	case <-timer.C:
+	}
+
+	jobID := newID(8)
+	e.metrics.recordTimeout(req)
+	e.Logger().Warn("time budget exceeded",
+		append(req.logAttrs(),
+			slog.Duration("budget", budget),
+			slog.String("job_id", jobID),
+		)...,
+	)
+	span.SetAttribute("a3go.job_id", jobID)
+
+	go func() {
+		err := <-done
+		span.SetError(err)
+		span.End()
+		response := e.respond(registration, buf.String())
+		if err != nil {
+			response = AsError(err).SQF()
+		}
+		err = e.sendArmaCallback(req.Context(), e.name(), PendingCallbackFunction, ToArmaHashMap([]interface{}{jobID, response}))
+		if err != nil {
+			e.Logger().Warn("error sending pending result",
+				append(req.logAttrs(),
+					slog.String("job_id", jobID),
+					slog.String("error", err.Error()),
+				)...,
+			)
+		}
+	}()
+	return pendingEnvelope(jobID)
+}
+
+// pendingEnvelope tells Arma that the result of a call will be delivered by callback as ["pending", jobID]
+func pendingEnvelope(jobID string) string {
+	return fmt.Sprintf(`["pending", "%s"]`, jobID)
+}
+
+// isPendingEnvelope returns true if a response is ["pending", jobID]
+func isPendingEnvelope(response string) bool {
+	return strings.HasPrefix(response, `["pending", `)
+}
@@ -0,0 +1,69 @@
+package a3interface
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRVExtensionRegistration_SetTimeBudget(t *testing.T) {
+	e := NewExtension()
+	release := make(chan struct{})
+	err := e.Register(NewRegistration("budgetSlow").
+		SetTimeBudget(10 * time.Millisecond).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			<-release
+			_, err := req.Writer.WriteString(`["done"]`)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	var deadline time.Duration
+	err = e.Register(NewRegistration("budgetFast").
+		SetTimeBudget(time.Second).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			if d, ok := req.Context().Deadline(); ok {
+				deadline = time.Until(d)
+			}
+			_, err := req.Writer.WriteString(`["fast"]`)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if got := e.dispatch(CallFormString, "budgetFast", nil, 10240); got != `["fast"]` {
+		t.Errorf("dispatch() = %v, want %v", got, `["fast"]`)
+	}
+	if deadline <= 0 || deadline > time.Second {
+		t.Errorf("context deadline in %v, want within the budget", deadline)
+	}
+
+	subscription := e.Subscribe(8, EventCallbackDropped)
+	defer subscription.Unsubscribe()
+	e.SetExtensionName("budgetExtension")
+
+	got := e.dispatch(CallFormString, "budgetSlow", nil, 10240)
+	if !strings.HasPrefix(got, `["pending", "`) {
+		t.Fatalf("dispatch() = %v, want pending envelope", got)
+	}
+	if timeouts := e.Metrics().Commands["budgetSlow"].Timeouts; timeouts != 1 {
+		t.Errorf("Timeouts = %v, want 1", timeouts)
+	}
+
+	// the result is sent by callback once the handler finishes, which is
+	// dropped here because no callback function is registered
+	close(release)
+	select {
+	case event := <-subscription.C:
+		if event.Message != "budgetExtension pending: callback function not set" {
+			t.Errorf("event.Message = %v, want pending callback", event.Message)
+		}
+		if event.Call == nil || event.Call.Command != "budgetSlow" {
+			t.Errorf("event.Call = %+v, want the call of budgetSlow", event.Call)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no pending callback sent")
+	}
+}
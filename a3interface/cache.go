@@ -0,0 +1,238 @@
+package a3interface
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of responses cached for a registration if SetCache is given no size
+const DefaultCacheSize = 1024
+
+// cacheEntry is a cached response. request is the key without the caller, see requestCacheKey
+type cacheEntry struct {
+	key      string
+	request  string
+	response string
+	expires  time.Time
+}
+
+// lruCache holds the cached responses of a registration, most recently used first
+type lruCache struct {
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+// flightKey identifies a running call that identical calls can wait for
+type flightKey struct {
+	registration *RVExtensionRegistration
+	key          string
+}
+
+// flight is a running call. response is set before done is closed
+type flight struct {
+	done     chan struct{}
+	response string
+}
+
+// responseCache holds the cached responses and running calls of every registration
+type responseCache struct {
+	mu      sync.Mutex
+	caches  map[*RVExtensionRegistration]*lruCache
+	flights map[flightKey]*flight
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		caches:  make(map[*RVExtensionRegistration]*lruCache),
+		flights: make(map[flightKey]*flight),
+	}
+}
+
+// requestCacheKey identifies identical calls by call form, command and arguments. Commands matched regardless of case are lower cased, so that every casing shares the key
+func requestCacheKey(form CallForm, command string, caseInsensitive bool, args []string) string {
+	if caseInsensitive {
+		command = strings.ToLower(command)
+	}
+	return form.String() + "\x00" + command + "\x00" + strings.Join(args, "\x00")
+}
+
+// callerCacheKey adds the caller to the key of a request, for registrations cached per caller
+func callerCacheKey(request string, ctx ArmaExtensionContext) string {
+	return request + "\x00" + ctx.SteamID + "\x00" + ctx.RemoteExecutedOwner
+}
+
+// get returns the cached response for key if it has not expired
+func (c *responseCache) get(registration *RVExtensionRegistration, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache, ok := c.caches[registration]
+	if !ok {
+		return "", false
+	}
+	element, ok := cache.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return "", false
+	}
+	cache.order.MoveToFront(element)
+	return entry.response, true
+}
+
+// set caches a response of request for the registration's TTL, evicting the least recently used responses beyond its size
+func (c *responseCache) set(registration *RVExtensionRegistration, request string, key string, response string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache, ok := c.caches[registration]
+	if !ok {
+		cache = &lruCache{
+			maxEntries: registration.CacheSize,
+			order:      list.New(),
+			entries:    make(map[string]*list.Element),
+		}
+		if cache.maxEntries <= 0 {
+			cache.maxEntries = DefaultCacheSize
+		}
+		c.caches[registration] = cache
+	}
+
+	entry := &cacheEntry{key: key, request: request, response: response, expires: time.Now().Add(registration.CacheTTL)}
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(entry)
+	for cache.order.Len() > cache.maxEntries {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// do runs fn unless an identical call is already running, in which case it waits for that call and returns its response with shared set to true
+func (c *responseCache) do(registration *RVExtensionRegistration, key string, fn func() string) (response string, shared bool) {
+	fk := flightKey{registration: registration, key: key}
+	c.mu.Lock()
+	if f, ok := c.flights[fk]; ok {
+		c.mu.Unlock()
+		<-f.done
+		return f.response, true
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[fk] = f
+	c.mu.Unlock()
+
+	// serve recovers panics, but release the waiting calls whatever happens
+	defer func() {
+		c.mu.Lock()
+		delete(c.flights, fk)
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	f.response = fn()
+	return f.response, false
+}
+
+// invalidate removes the cached responses of a registration to requests, of every caller, or all of them if no requests are given
+func (c *responseCache) invalidate(registration *RVExtensionRegistration, requests ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache, ok := c.caches[registration]
+	if !ok {
+		return
+	}
+	if len(requests) == 0 {
+		delete(c.caches, registration)
+		return
+	}
+	for key, element := range cache.entries {
+		entry := element.Value.(*cacheEntry)
+		for _, request := range requests {
+			if entry.request == request {
+				cache.order.Remove(element)
+				delete(cache.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// clear removes every cached response
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.caches = make(map[*RVExtensionRegistration]*lruCache)
+}
+
+// serveCached answers a synchronous call from the registration's cache, or by sharing the response of an identical running call, before running the handler
+func (e *Extension) serveCached(handler Handler, req *Request, match routeMatch, span *Span) string {
+	registration := match.registration
+	request := requestCacheKey(req.Form, req.Command, match.caseInsensitive, req.Args)
+	key := request
+	if registration.CachePerCaller {
+		key = callerCacheKey(request, req.ArmaContext)
+	}
+	if registration.CacheTTL > 0 {
+		if response, ok := e.cache.get(registration, key); ok {
+			e.metrics.recordCacheHit(req)
+			span.SetAttribute("a3go.cache", "hit")
+			span.End()
+			return response
+		}
+	}
+
+	run := func() string {
+		response := e.serveSync(handler, req, registration, span)
+		if registration.CacheTTL > 0 && !isErrorEnvelope(response) && !isPendingEnvelope(response) {
+			e.cache.set(registration, request, key, response)
+		}
+		return response
+	}
+	if !registration.Coalesce {
+		return run()
+	}
+	response, shared := e.cache.do(registration, key, run)
+	if shared {
+		e.metrics.recordCoalesced(req)
+		span.SetAttribute("a3go.cache", "coalesced")
+		span.End()
+	}
+	return response
+}
+
+// InvalidateCache removes every cached response of the registration that command routes to
+func (e *Extension) InvalidateCache(command string) error {
+	registration := e.router.lookup(command, false).registration
+	if registration == nil {
+		return fmt.Errorf("command %s not registered", command)
+	}
+	e.cache.invalidate(registration)
+	return nil
+}
+
+// InvalidateCacheEntry removes the cached responses of command called with args, in both call forms and for every caller. Args are compared without escape quotes, i.e. InvalidateCacheEntry("leaderboard", "10"). If the command is matched regardless of case, responses to every casing are removed
+func (e *Extension) InvalidateCacheEntry(command string, args ...string) error {
+	match := e.router.lookup(command, false)
+	if match.registration == nil {
+		return fmt.Errorf("command %s not registered", command)
+	}
+	e.cache.invalidate(match.registration,
+		requestCacheKey(CallFormString, command, match.caseInsensitive, args),
+		requestCacheKey(CallFormArgs, command, match.caseInsensitive, args),
+	)
+	return nil
+}
+
+// ClearCache removes every cached response of every registration
+func (e *Extension) ClearCache() {
+	e.cache.clear()
+}
@@ -0,0 +1,183 @@
+package a3interface
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRVExtensionRegistration_SetCache(t *testing.T) {
+	e := NewExtension()
+	var calls atomic.Int32
+	err := e.Register(NewRegistration("cacheLeaderboard").
+		SetCache(time.Minute, 2).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			if len(req.Args) > 0 && req.Args[0] == "fail" {
+				return fmt.Errorf("no leaderboard")
+			}
+			_, err := fmt.Fprintf(req.Writer, `["%s", %d]`, strings.Join(req.Args, ","), calls.Add(1))
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	call := func(args ...string) string {
+		return e.dispatch(CallFormString, strings.Join(append([]string{"cacheLeaderboard"}, args...), "|"), nil, 10240)
+	}
+
+	tests := []struct {
+		name       string
+		invalidate func()
+		args       []string
+		want       string
+	}{
+		{name: "first call", args: []string{"10"}, want: `["10", 1]`},
+		{name: "cached", args: []string{"10"}, want: `["10", 1]`},
+		{name: "other args", args: []string{"20"}, want: `["20", 2]`},
+		{name: "errors are not cached", args: []string{"fail"}, want: `["error", "HANDLER_ERROR", "no leaderboard", [], false]`},
+		{name: "evicts least recently used", args: []string{"30"}, want: `["30", 3]`},
+		{name: "evicted", args: []string{"10"}, want: `["10", 4]`},
+		{
+			name:       "entry invalidated",
+			invalidate: func() { e.InvalidateCacheEntry("cacheLeaderboard", "10") },
+			args:       []string{"10"},
+			want:       `["10", 5]`,
+		},
+		{
+			name:       "registration invalidated",
+			invalidate: func() { e.InvalidateCache("cacheLeaderboard") },
+			args:       []string{"30"},
+			want:       `["30", 6]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.invalidate != nil {
+				tt.invalidate()
+			}
+			if got := call(tt.args...); got != tt.want {
+				t.Errorf("dispatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if hits := e.Metrics().Commands["cacheLeaderboard"].CacheHits; hits != 1 {
+		t.Errorf("CacheHits = %v, want 1", hits)
+	}
+}
+
+func TestRVExtensionRegistration_SetCoalesce(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	e := NewExtension()
+	err := e.Register(NewRegistration("coalesceConfig").
+		SetCoalesce(true).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			<-release
+			_, err := fmt.Fprintf(req.Writer, `[%d]`, calls.Add(1))
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	responses := make([]string, 5)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = e.dispatch(CallFormString, "coalesceConfig", nil, 10240)
+		}(i)
+	}
+
+	// give the calls time to join the running one before letting it finish
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		e.cache.mu.Lock()
+		running := len(e.cache.flights)
+		e.cache.mu.Unlock()
+		if running > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, response := range responses {
+		if response != "[1]" {
+			t.Errorf("dispatch() = %v, want [1]", response)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+	if got := e.Metrics().Commands["coalesceConfig"].Coalesced; got != 4 {
+		t.Errorf("Coalesced = %v, want 4", got)
+	}
+}
+
+func TestRVExtensionRegistration_SetCachePerCaller(t *testing.T) {
+	tests := []struct {
+		name      string
+		perCaller bool
+		want      []string
+	}{
+		{name: "shared", perCaller: false, want: []string{`["1"]`, `["1"]`, `["1"]`}},
+		{name: "per caller", perCaller: true, want: []string{`["1"]`, `["2"]`, `["1"]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExtension()
+			err := e.Register(NewRegistration("cacheMyStats").
+				SetCache(time.Minute, 0).
+				SetCachePerCaller(tt.perCaller).
+				SetHandler(HandlerFunc(func(req *Request) error {
+					_, err := fmt.Fprintf(req.Writer, `["%s"]`, req.ArmaContext.SteamID)
+					return err
+				})))
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			for i, steamID := range []string{"1", "2", "1"} {
+				e.SetContext(ArmaExtensionContext{SteamID: steamID})
+				if got := e.dispatch(CallFormString, "cacheMyStats", nil, 10240); got != tt.want[i] {
+					t.Errorf("dispatch() call %d by %s = %v, want %v", i+1, steamID, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtension_InvalidateCacheEntry_caseInsensitive(t *testing.T) {
+	e := NewExtension()
+	e.SetCaseInsensitive(true)
+	var calls atomic.Int32
+	err := e.Register(NewRegistration("cacheScores").
+		SetCache(time.Minute, 0).
+		SetCachePerCaller(true).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := fmt.Fprintf(req.Writer, `[%d]`, calls.Add(1))
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if got := e.dispatch(CallFormString, "CACHESCORES|10", nil, 10240); got != "[1]" {
+		t.Fatalf("dispatch() = %v, want [1]", got)
+	}
+	if got := e.dispatch(CallFormString, "cacheScores|10", nil, 10240); got != "[1]" {
+		t.Errorf("dispatch() of other casing = %v, want cached [1]", got)
+	}
+	if err := e.InvalidateCacheEntry("CacheScores", "10"); err != nil {
+		t.Fatalf("InvalidateCacheEntry() error = %v", err)
+	}
+	if got := e.dispatch(CallFormString, "CACHESCORES|10", nil, 10240); got != "[2]" {
+		t.Errorf("dispatch() after InvalidateCacheEntry() = %v, want [2]", got)
+	}
+}
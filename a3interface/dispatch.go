@@ -0,0 +1,269 @@
+package a3interface
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// dispatch finds the registration for a call from Arma, runs its handler and returns the string that should be written to Arma's output buffer
+func (e *Extension) dispatch(form CallForm, input string, data []string, outputSize int) string {
+	start := time.Now()
+	req, match := e.newRequest(form, input, data, outputSize)
+	response := e.finish(req, e.handle(req, match))
+	e.record(RecordEntry{
+		Time:       start,
+		Type:       RecordCall,
+		Form:       form,
+		Input:      input,
+		Args:       data,
+		OutputSize: outputSize,
+		Response:   response,
+	})
+	return response
+}
+
+// newRequest builds the request for a call from Arma and routes its command
+func (e *Extension) newRequest(form CallForm, input string, data []string, outputSize int) (*Request, routeMatch) {
+	req := &Request{
+		RawInput:    input,
+		Form:        form,
+		ArmaContext: e.Context(),
+		OutputSize:  outputSize,
+		ext:         e,
+	}
+
+	// look for registration
+	var match routeMatch
+	switch form {
+	case CallFormArgs:
+		match = e.router.route(RemoveEscapeQuotes(input))
+		req.Command = RemoveEscapeQuotes(input)
+		req.RawArgs = data
+		req.Args = make([]string, len(data))
+		for index, item := range data {
+			req.Args[index] = RemoveEscapeQuotes(item)
+		}
+	default:
+		req.Command = input
+		match = e.router.lookup(input, false)
+		if match.registration == nil {
+			parts := strings.Split(input, "|")
+			req.Command = parts[0]
+			req.Args = parts[1:]
+			req.RawArgs = parts[1:]
+			match = e.router.route(req.Command)
+		}
+	}
+	req.Params = match.params
+	req.metricsKey = metricsKeyUnregistered
+	if match.registration != nil {
+		req.Pattern = match.command()
+		req.metricsKey = req.Pattern
+		if match.registration.fallback {
+			req.metricsKey = metricsKeyFallback
+		}
+	}
+	return req, match
+}
+
+// handle validates the arguments of a routed request and runs its handler, returning the response for Arma
+func (e *Extension) handle(req *Request, match routeMatch) string {
+	registration := match.registration
+	background := registration != nil && registration.RunInBackground
+	// callbacks sent with the request's context are reported with the call
+	req.ctx = context.WithValue(req.Context(), callContextKey{}, req.callInfo(background))
+	span := startCallSpan(req, background)
+	if registration == nil {
+		err := Errorf(ErrCodeNotRegistered, "command %s not registered", req.Command)
+		e.publishCallError(req, err, false, 0)
+		span.SetError(err)
+		span.End()
+		return err.SQF()
+	}
+
+	// deny callers before anything else, so that they learn nothing about
+	// the command's arguments
+	if err := e.checkAccess(req, match); err != nil {
+		// checkAccess logged the denial with the caller
+		e.recordCallError(req, err, false, 0)
+		span.SetError(err)
+		span.End()
+		return AsError(err).SQF()
+	}
+
+	// validate arguments before anything is run, so that background
+	// registrations report bad calls instead of their default response
+	if registration.ArgSchema != nil {
+		values, err := registration.ArgSchema.Validate(req.Command, req.Form, req.RawArgs)
+		if err != nil {
+			e.publishCallError(req, err, false, 0)
+			span.SetError(err)
+			span.End()
+			return AsError(err).SQF()
+		}
+		req.Values = values
+	}
+
+	// rate limits are checked before anything is run too, queued calls
+	// wait before their handler is called
+	wait, err := e.rateLimiter.admit(req, registration)
+	if err != nil {
+		e.publishCallError(req, err, false, 0)
+		span.SetError(err)
+		span.End()
+		return AsError(err).SQF()
+	}
+
+	handler := chainMiddleware(
+		registration.handler(),
+		joinMiddleware(match.middleware, registration.Middleware),
+	)
+	if wait > 0 {
+		handler = delayed(handler, wait)
+	}
+
+	// if RunInBackground is true for this registration, send default response
+	// to Arma and run the handler in the background
+	// data can be sent back to arma using WriteArmaCallback
+	if registration.RunInBackground {
+		req.Writer = &responseBuffer{}
+		job := func() {
+			span.SetError(e.serve(handler, req, true))
+			span.End()
+		}
+		// jobs with an ordering key wait for earlier jobs with the same key
+		if key := orderingKey(registration, req); key != "" {
+			e.jobs.submit(key, job)
+		} else {
+			go job()
+		}
+		return e.respond(registration, withTraceID(req, registration.DefaultResponse))
+	}
+
+	// otherwise, Arma is awaiting a reply
+	if registration.Coalesce || registration.CacheTTL > 0 {
+		return e.serveCached(handler, req, match, span)
+	}
+	return e.serveSync(handler, req, registration, span)
+}
+
+// serveSync runs a handler while Arma waits, for at most the registration's time budget if one is set
+func (e *Extension) serveSync(handler Handler, req *Request, registration *RVExtensionRegistration, span *Span) string {
+	if registration.TimeBudget > 0 {
+		return e.serveWithBudget(handler, req, registration, span)
+	}
+	buf := &responseBuffer{}
+	req.Writer = buf
+	err := e.serve(handler, req, false)
+	span.SetError(err)
+	span.End()
+	if err != nil {
+		return AsError(err).SQF()
+	}
+	return e.respond(registration, buf.String())
+}
+
+// finish truncates the response to fit Arma's output buffer and records the call's metrics
+func (e *Extension) finish(req *Request, response string) string {
+	truncated := false
+	if req.OutputSize > 0 && len(response) > req.OutputSize-1 {
+		response = response[:req.OutputSize-1]
+		truncated = true
+		e.Logger().Warn("response truncated",
+			append(req.logAttrs(), slog.Int("output_size", req.OutputSize))...,
+		)
+	}
+	e.metrics.recordCall(req, len(response), truncated)
+	return response
+}
+
+// respond wraps a successful response in the ok envelope if it is enabled globally or for the registration
+func (e *Extension) respond(registration *RVExtensionRegistration, response string) string {
+	if e.responseEnvelope.Load() || registration.ResponseEnvelope {
+		return okEnvelope(response)
+	}
+	return response
+}
+
+// serve runs a handler, recovering any panic and publishing events for errors, panics and slow calls
+func (e *Extension) serve(handler Handler, req *Request, background bool) (err error) {
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		if r := recover(); r != nil {
+			err = panicError(req, r)
+		}
+		e.metrics.recordDuration(req, background, duration)
+		if err != nil {
+			e.publishCallError(req, err, background, duration)
+		} else {
+			e.Logger().Debug("call handled",
+				append(req.logAttrs(),
+					slog.Bool("background", background),
+					slog.Duration("duration", duration),
+				)...,
+			)
+		}
+		if threshold := time.Duration(e.slowCallThreshold.Load()); threshold > 0 && duration > threshold {
+			e.Logger().Warn("slow call",
+				append(req.logAttrs(), slog.Duration("duration", duration))...,
+			)
+			e.events.publish(Event{
+				Type:     EventSlowCall,
+				Call:     req.callInfo(background),
+				Duration: duration,
+				Message:  fmt.Sprintf("command %s took %s", req.Command, duration),
+			})
+		}
+	}()
+	return handler.ServeRV(req)
+}
+
+// panicError converts a recovered panic to an ErrCodePanic error, keeping the stack trace in the wrapped error
+func panicError(req *Request, r interface{}) *Error {
+	return Errorf(ErrCodePanic, "panic in command %s: %v", req.Command, r).
+		Wrap(fmt.Errorf("%v\n%s", r, debug.Stack()))
+}
+
+// publishCallError logs a failed call and publishes EventPanic for ErrCodePanic errors and EventError for anything else
+func (e *Extension) publishCallError(req *Request, err error, background bool, duration time.Duration) {
+	level := slog.LevelWarn
+	if AsError(err).Code == ErrCodePanic {
+		level = slog.LevelError
+	}
+	e.Logger().Log(context.Background(), level, "call failed",
+		append(req.logAttrs(),
+			slog.Bool("background", background),
+			slog.Duration("duration", duration),
+			slog.String("error", err.Error()),
+		)...,
+	)
+	e.recordCallError(req, err, background, duration)
+}
+
+// recordCallError counts a failed call, keeps it for the errors introspection command and publishes its event, without logging it
+func (e *Extension) recordCallError(req *Request, err error, background bool, duration time.Duration) {
+	e.metrics.recordError(req, err)
+	armaErr := AsError(err)
+	e.recentErrors.add(recentError{
+		time:    time.Now(),
+		command: req.Command,
+		code:    armaErr.Code,
+		message: armaErr.Message,
+	})
+	eventType := EventError
+	if armaErr.Code == ErrCodePanic {
+		eventType = EventPanic
+	}
+	e.events.publish(Event{
+		Type:     eventType,
+		Call:     req.callInfo(background),
+		Err:      err,
+		Duration: duration,
+		Message:  err.Error(),
+	})
+}
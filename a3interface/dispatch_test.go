@@ -0,0 +1,150 @@
+package a3interface
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_dispatch(t *testing.T) {
+	e := NewExtension()
+	err := e.Register(NewRegistration("dispatchHandler").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(
+				req.Form.String() + ":" + req.Command + ":" + strings.Join(req.Args, ","),
+			)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	err = e.Register(NewRegistration("dispatchLegacy").
+		SetFunction(func(ctx ArmaExtensionContext, data string) (string, error) {
+			return "function:" + data, nil
+		}).
+		SetArgsFunction(func(ctx ArmaExtensionContext, command string, args []string) (string, error) {
+			return "argsFunction:" + command + ":" + strings.Join(args, ","), nil
+		}))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	err = e.Register(NewRegistration("dispatchError").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			return errors.New("bad data")
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	err = e.Register(NewRegistration("dispatchStructuredError").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			return NewError("DB_BUSY", "database is busy").
+				SetDetails([]interface{}{"players"}).
+				SetRetryable(true)
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	err = e.Register(NewRegistration("dispatchEnvelope").
+		SetResponseEnvelope(true).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(`["saved"]`)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	type args struct {
+		form  CallForm
+		input string
+		data  []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "handler string form",
+			args: args{
+				form:  CallFormString,
+				input: "dispatchHandler|a|b",
+			},
+			want: "RVExtension:dispatchHandler:a,b",
+		},
+		{
+			name: "handler args form",
+			args: args{
+				form:  CallFormArgs,
+				input: "dispatchHandler",
+				data:  []string{`"a"`, `"b"`},
+			},
+			want: "RVExtensionArgs:dispatchHandler:a,b",
+		},
+		{
+			name: "legacy function",
+			args: args{
+				form:  CallFormString,
+				input: "dispatchLegacy|a",
+			},
+			want: "function:dispatchLegacy|a",
+		},
+		{
+			name: "legacy args function",
+			args: args{
+				form:  CallFormArgs,
+				input: "dispatchLegacy",
+				data:  []string{"a"},
+			},
+			want: "argsFunction:dispatchLegacy:a",
+		},
+		{
+			name: "error string form",
+			args: args{
+				form:  CallFormString,
+				input: "dispatchError",
+			},
+			want: `["error", "HANDLER_ERROR", "bad data", [], false]`,
+		},
+		{
+			name: "error args form",
+			args: args{
+				form:  CallFormArgs,
+				input: "dispatchError",
+			},
+			want: `["error", "HANDLER_ERROR", "bad data", [], false]`,
+		},
+		{
+			name: "structured error",
+			args: args{
+				form:  CallFormArgs,
+				input: "dispatchStructuredError",
+			},
+			want: `["error", "DB_BUSY", "database is busy", ["players"], true]`,
+		},
+		{
+			name: "ok envelope",
+			args: args{
+				form:  CallFormString,
+				input: "dispatchEnvelope",
+			},
+			want: `["ok", ["saved"]]`,
+		},
+		{
+			name: "not registered",
+			args: args{
+				form:  CallFormString,
+				input: "dispatchMissing",
+			},
+			want: `["error", "NOT_REGISTERED", "command dispatchMissing not registered", [], false]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.dispatch(tt.args.form, tt.args.input, tt.args.data, 10240); got != tt.want {
+				t.Errorf("dispatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package a3interface
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error codes used by the library. Handlers may use these or define their own
+const (
+	// ErrCodeNotRegistered is returned when no registration matches a command
+	ErrCodeNotRegistered = "NOT_REGISTERED"
+	// ErrCodeHandlerNotSet is returned when a registration has no function for the call form used
+	ErrCodeHandlerNotSet = "HANDLER_NOT_SET"
+	// ErrCodeInvalidArgs is returned when arguments do not match the registration's ArgSchema
+	ErrCodeInvalidArgs = "INVALID_ARGS"
+	// ErrCodeHandler is used for errors returned by a handler that are not an *Error
+	ErrCodeHandler = "HANDLER_ERROR"
+	// ErrCodePanic is returned when a handler or middleware panics. The dispatcher always recovers panics, RecoverMiddleware only lets middleware further out see the error
+	ErrCodePanic = "PANIC"
+	// ErrCodeRateLimited is returned when a call is rejected by a rate limit
+	ErrCodeRateLimited = "RATE_LIMITED"
+	// ErrCodeAccessDenied is returned when a call is denied by an AccessPolicy
+	ErrCodeAccessDenied = "ACCESS_DENIED"
+)
+
+// Error is a structured error that is returned to Arma as the envelope
+// ["error", code, message, details, retryable]
+type Error struct {
+	// Code is a short machine-readable identifier, i.e. "INVALID_ARGS"
+	Code string
+	// Message is a human-readable description
+	Message string
+	// Details is any value that can be converted by ToArmaHashMap. It is sent as an empty array if nil
+	Details interface{}
+	// Retryable tells the caller whether the same call may succeed later
+	Retryable bool
+	// Err is the underlying error, if any
+	Err error
+}
+
+// NewError returns an Error with a code and message
+func NewError(code string, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Errorf returns an Error with a code and a message formatted with fmt.Sprintf. If the arguments include an error, it is wrapped
+func Errorf(code string, format string, a ...interface{}) *Error {
+	e := NewError(code, fmt.Sprintf(format, a...))
+	for _, arg := range a {
+		if err, ok := arg.(error); ok {
+			e.Err = err
+			break
+		}
+	}
+	return e
+}
+
+// SetDetails sets the details sent to Arma with the error
+func (e *Error) SetDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+// SetRetryable determines whether the caller is told the same call may succeed later
+func (e *Error) SetRetryable(retryable bool) *Error {
+	e.Retryable = retryable
+	return e
+}
+
+// Wrap sets the underlying error
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// SQF returns the error envelope as an SQF array that can be parsed with parseSimpleArray
+func (e *Error) SQF() string {
+	details := e.Details
+	if details == nil {
+		details = []interface{}{}
+	}
+	return fmt.Sprintf(`["error", %s, %s, %s, %t]`,
+		ToArmaHashMap(e.Code),
+		ToArmaHashMap(e.Message),
+		ToArmaHashMap(details),
+		e.Retryable,
+	)
+}
+
+// AsError converts any error to an *Error. An *Error in the chain of err is returned as is, a *ValidationError is converted to ErrCodeInvalidArgs and anything else to ErrCodeHandler
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return NewError(ErrCodeInvalidArgs, verr.Error()).
+			SetDetails(verr.details()).
+			Wrap(err)
+	}
+	return NewError(ErrCodeHandler, err.Error()).Wrap(err)
+}
+
+// okEnvelope wraps a successful response as ["ok", response]. The response is expected to be an SQF value, an empty response is sent as an empty string
+func okEnvelope(response string) string {
+	if response == "" {
+		response = `""`
+	}
+	return fmt.Sprintf(`["ok", %s]`, response)
+}
+
+// isErrorEnvelope returns true if a response is an error sent as ["error", code, message, details, retryable]
+func isErrorEnvelope(response string) bool {
+	return strings.HasPrefix(response, `["error", `)
+}
@@ -0,0 +1,170 @@
+package a3interface
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies the kind of an Event
+type EventType string
+
+const (
+	// EventError is published when a call fails, including calls to unregistered commands and arguments rejected by a schema
+	EventError EventType = "error"
+	// EventPanic is published when a handler panics. The dispatcher recovers the panic and reports it to Arma as a PANIC error
+	EventPanic EventType = "panic"
+	// EventSlowCall is published when a handler takes longer than the threshold set with SetSlowCallThreshold
+	EventSlowCall EventType = "slow_call"
+	// EventCallbackDropped is published when WriteArmaCallback could not deliver a callback to Arma. Its Call is the call that sent the callback if it was sent with WriteArmaCallbackContext and the request's context, or is the pending result of a call that exceeded its time budget
+	EventCallbackDropped EventType = "callback_dropped"
+	// EventRegistration is published when a registration is added or a router is mounted. It is not caused by a call, so its Call is always nil
+	EventRegistration EventType = "registration"
+	// EventThrottled is published when a call is over a rate limit, whether it is rejected or queued
+	EventThrottled EventType = "throttled"
+	// EventAccessDenied is published when a call is denied by an AccessPolicy, as an audit record of who tried to call what
+	EventAccessDenied EventType = "access_denied"
+)
+
+// CallInfo is a snapshot of the call an Event relates to
+type CallInfo struct {
+	// Command is the command Arma called
+	Command string
+	// Pattern is the Command of the registration that matched the call, with the prefixes of the routers it is mounted under
+	Pattern string
+	// Form is the call form Arma used
+	Form CallForm
+	// Args are the arguments of the call, with escape quotes removed
+	Args []string
+	// ArmaContext is the context Arma sent just before the call
+	ArmaContext ArmaExtensionContext
+	// Background is true if the handler ran in the background
+	Background bool
+}
+
+// Event describes something that happened in the extension
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Call is the call the event relates to. It is always set, except for EventRegistration and for EventCallbackDropped of callbacks sent without the context of a request
+	Call *CallInfo
+	// Err is the error for EventError and EventPanic
+	Err error
+	// Duration is the time the handler took for EventSlowCall, EventError and EventPanic
+	Duration time.Duration
+	// Message describes the event, i.e. the registered command for EventRegistration or the callback function for EventCallbackDropped
+	Message string
+}
+
+// Subscription receives published events on C until Unsubscribe is called. Events are never blocked on: if C is full, the event is dropped and counted
+type Subscription struct {
+	// C receives the events this subscription was created for. It is closed by Unsubscribe
+	C <-chan Event
+
+	c       chan Event
+	types   map[EventType]bool
+	dropped atomic.Uint64
+	bus     *eventBus
+}
+
+// Dropped returns the number of events that were dropped because C was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops delivery of events and closes C
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
+
+// eventBus delivers events to every matching subscription
+type eventBus struct {
+	mu            sync.RWMutex
+	subscriptions []*Subscription
+}
+
+func (b *eventBus) subscribe(bufferSize int, types []EventType) *Subscription {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	c := make(chan Event, bufferSize)
+	s := &Subscription{
+		C:   c,
+		c:   c,
+		bus: b,
+	}
+	if len(types) > 0 {
+		s.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, s)
+	return s
+}
+
+func (b *eventBus) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for index, sub := range b.subscriptions {
+		if sub == s {
+			b.subscriptions = append(b.subscriptions[:index], b.subscriptions[index+1:]...)
+			close(s.c)
+			return
+		}
+	}
+}
+
+// publish delivers an event to every matching subscription without blocking
+func (b *eventBus) publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subscriptions {
+		if s.types != nil && !s.types[event.Type] {
+			continue
+		}
+		select {
+		case s.c <- event:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe returns a Subscription that receives events of the given types, or every event if no types are given. bufferSize bounds the number of undelivered events, after which new events are dropped
+func (e *Extension) Subscribe(bufferSize int, types ...EventType) *Subscription {
+	return e.events.subscribe(bufferSize, types)
+}
+
+// SetSlowCallThreshold sets the duration after which a call publishes EventSlowCall. A threshold of 0 disables slow call events
+func (e *Extension) SetSlowCallThreshold(threshold time.Duration) {
+	e.slowCallThreshold.Store(int64(threshold))
+}
+
+// callContextKey holds the *CallInfo of the call a request's context belongs to
+type callContextKey struct{}
+
+// callFromContext returns the call ctx belongs to, or nil
+func callFromContext(ctx context.Context) *CallInfo {
+	call, _ := ctx.Value(callContextKey{}).(*CallInfo)
+	return call
+}
+
+// callInfo returns a snapshot of the request for events
+func (r *Request) callInfo(background bool) *CallInfo {
+	return &CallInfo{
+		Command:     r.Command,
+		Pattern:     r.Pattern,
+		Form:        r.Form,
+		Args:        append([]string(nil), r.Args...),
+		ArmaContext: r.ArmaContext,
+		Background:  background,
+	}
+}
@@ -0,0 +1,130 @@
+package a3interface
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_eventBus_publish(t *testing.T) {
+	bus := &eventBus{}
+	all := bus.subscribe(2, nil)
+	errorsOnly := bus.subscribe(10, []EventType{EventError})
+
+	bus.publish(Event{Type: EventError, Err: errors.New("first")})
+	bus.publish(Event{Type: EventSlowCall})
+	bus.publish(Event{Type: EventError, Err: errors.New("second")})
+
+	if got := len(all.C); got != 2 {
+		t.Errorf("len(all.C) = %v, want %v", got, 2)
+	}
+	if got := all.Dropped(); got != 1 {
+		t.Errorf("all.Dropped() = %v, want %v", got, 1)
+	}
+	if got := len(errorsOnly.C); got != 2 {
+		t.Errorf("len(errorsOnly.C) = %v, want %v", got, 2)
+	}
+	if got := errorsOnly.Dropped(); got != 0 {
+		t.Errorf("errorsOnly.Dropped() = %v, want %v", got, 0)
+	}
+
+	event := <-all.C
+	if event.Time.IsZero() {
+		t.Errorf("event.Time is zero")
+	}
+
+	all.Unsubscribe()
+	bus.publish(Event{Type: EventError})
+	for range all.C {
+	}
+	if got := len(errorsOnly.C); got != 3 {
+		t.Errorf("len(errorsOnly.C) = %v, want %v", got, 3)
+	}
+}
+
+func Test_dispatch_panicEvent(t *testing.T) {
+	e := NewExtension()
+	subscription := e.Subscribe(10, EventPanic)
+	defer subscription.Unsubscribe()
+
+	err := e.Register(NewRegistration("eventsPanic").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			var args []string
+			_ = args[3]
+			return nil
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	got := e.dispatch(CallFormArgs, "eventsPanic", []string{`"a"`}, 10240)
+	if !strings.HasPrefix(got, `["error", "PANIC", `) {
+		t.Errorf("dispatch() = %v, want PANIC error", got)
+	}
+
+	select {
+	case event := <-subscription.C:
+		if event.Call == nil || event.Call.Command != "eventsPanic" {
+			t.Errorf("event.Call = %+v, want command eventsPanic", event.Call)
+		}
+		if AsError(event.Err).Code != ErrCodePanic {
+			t.Errorf("event.Err = %v, want code %v", event.Err, ErrCodePanic)
+		}
+	default:
+		t.Errorf("no EventPanic published")
+	}
+}
+
+func Test_callbackDroppedEvent(t *testing.T) {
+	e := NewExtension()
+	subscription := e.Subscribe(10, EventCallbackDropped)
+	defer subscription.Unsubscribe()
+
+	err := e.Register(NewRegistration("eventsCallback").
+		SetRunInBackground(true).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			return req.Extension().WriteArmaCallbackContext(req.Context(), "eventsExtension", "loaded", req.Args...)
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	e.SetContext(ArmaExtensionContext{SteamID: "76561198000000001"})
+	e.dispatch(CallFormArgs, "eventsCallback", []string{`"a"`}, 10240)
+	if err := e.WriteArmaCallback("eventsExtension", "unrelated"); err == nil {
+		t.Fatal("WriteArmaCallback() error = nil, want callback function not set")
+	}
+
+	tests := []struct {
+		message string
+		want    *CallInfo
+	}{
+		{message: "eventsExtension unrelated: callback function not set", want: nil},
+		{
+			message: "eventsExtension loaded: callback function not set",
+			want: &CallInfo{
+				Command:     "eventsCallback",
+				Pattern:     "eventsCallback",
+				Form:        CallFormArgs,
+				Args:        []string{"a"},
+				ArmaContext: ArmaExtensionContext{SteamID: "76561198000000001"},
+				Background:  true,
+			},
+		},
+	}
+	events := map[string]Event{}
+	for len(events) < len(tests) {
+		select {
+		case event := <-subscription.C:
+			events[event.Message] = event
+		case <-time.After(time.Second):
+			t.Fatalf("received %d callback dropped events, want %d", len(events), len(tests))
+		}
+	}
+	for _, tt := range tests {
+		if got := events[tt.message].Call; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("event %q Call = %+v, want %+v", tt.message, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package a3interface
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/indig0fox/a3go/assemblyfinder"
+)
+
+// Extension owns the registrations, settings and state of an extension. The functions exported to Arma delegate to the default Extension, see Default. Instances created with NewExtension are isolated from it and from each other, so tests can register the same commands without sharing state
+type Extension struct {
+
+	// version is the value that will be returned when the extension is first called by Arma. This is a string value and is logged by the game engine to the RPT file
+	version atomic.Pointer[string]
+
+	// extensionName is the name callbacks sent by the library use, derived from the module file name if not set
+	extensionName atomic.Pointer[string]
+
+	// router holds the registrations that will be used to determine how to handle calls to the extension
+	router *Router
+
+	// jobs runs the background jobs of registrations with an OrderingKey
+	jobs *keyedQueue
+
+	// cache holds the cached responses and running calls of registrations with a cache or coalescing
+	cache *responseCache
+
+	// accessPolicies are the access policies loaded from a file
+	accessPolicies *accessPolicies
+
+	// rateLimiter holds the token buckets of the rate limits set globally and on registrations
+	rateLimiter *rateLimiter
+
+	// responseEnvelope wraps the successful responses of every registration as ["ok", response]
+	responseEnvelope atomic.Bool
+
+	// events delivers events to subscribers
+	events *eventBus
+
+	// slowCallThreshold is the time.Duration after which a call publishes EventSlowCall, 0 disables it
+	slowCallThreshold atomic.Int64
+
+	// logger receives log records from the library
+	logger atomic.Pointer[slog.Logger]
+
+	// metrics are recorded by the dispatcher for every call
+	metrics *metricsRegistry
+
+	// spanExporter receives finished spans, tracing is disabled if it is nil
+	spanExporter atomic.Pointer[SpanExporter]
+
+	// startTime is when the extension was created, reported by the uptime introspection command
+	startTime time.Time
+
+	// recentErrors are reported by the errors introspection command
+	recentErrors *errorRing
+
+	// healthChecks are run by the health introspection command
+	healthChecks *healthChecks
+
+	// recorder records the traffic of the extension, recording is disabled if it is nil
+	recorder atomic.Pointer[Recorder]
+
+	// errChanSubscription forwards errors to the channel set with RegisterErrorChan
+	errChanSubscription *Subscription
+
+	// context is the context Arma sent with RVExtensionContext, copied into every request
+	context atomic.Pointer[ArmaExtensionContext]
+
+	// callbackMu guards callback
+	callbackMu sync.RWMutex
+
+	// callback delivers callbacks to Arma, it is nil until Arma registers its callback function
+	callback CallbackFunc
+}
+
+// CallbackFunc delivers a callback to Arma, returning Arma's result. Arma returns a negative value if its callback queue is full
+type CallbackFunc func(name string, function string, data string) int
+
+// defaultExtension is the Extension the exported functions delegate to
+var defaultExtension = NewExtension()
+
+func init() {
+	// set the default version
+	defaultExtension.SetVersion("DEVELOPMENT")
+}
+
+// NewExtension returns an Extension with no registrations and the default settings
+func NewExtension() *Extension {
+	e := &Extension{
+		router:         NewRouter(),
+		jobs:           &keyedQueue{},
+		cache:          newResponseCache(),
+		rateLimiter:    newRateLimiter(),
+		accessPolicies: &accessPolicies{},
+		events:         &eventBus{},
+		metrics:        newMetricsRegistry(),
+		startTime:      time.Now(),
+		recentErrors:   &errorRing{},
+		healthChecks:   &healthChecks{},
+	}
+	e.SetVersion("No version set")
+	e.SetExtensionName("")
+	e.SetLogger(defaultLogger())
+	// blank context until Arma sends one
+	e.context.Store(&ArmaExtensionContext{
+		SteamID:           "123456789",
+		FileSource:        "test",
+		MissionNameSource: "test",
+		ServerName:        "test",
+	})
+	return e
+}
+
+// Default returns the Extension that the functions exported to Arma, NewRegistration(...).Register, SetVersion, WriteArmaCallback and RegisterErrorChan use
+func Default() *Extension {
+	return defaultExtension
+}
+
+// Version returns the version string, as RVExtensionVersion does
+func (e *Extension) Version() string {
+	return *e.version.Load()
+}
+
+// SetContext sets the context copied into the requests of the following calls, as RVExtensionContext does
+func (e *Extension) SetContext(ctx ArmaExtensionContext) {
+	// Arma sends the context before every call, only changes are recorded
+	if previous := e.context.Swap(&ctx); *previous != ctx {
+		e.record(RecordEntry{Type: RecordContext, Context: &ctx})
+	}
+}
+
+// Context returns the context copied into the requests of the following calls
+func (e *Extension) Context() ArmaExtensionContext {
+	return *e.context.Load()
+}
+
+// SetCallback sets the function callbacks are delivered with, as RVExtensionRegisterCallback does. Pass nil to drop callbacks
+func (e *Extension) SetCallback(callback CallbackFunc) {
+	e.callbackMu.Lock()
+	defer e.callbackMu.Unlock()
+	e.callback = callback
+}
+
+// callbackFunc returns the function callbacks are delivered with, or nil
+func (e *Extension) callbackFunc() CallbackFunc {
+	e.callbackMu.RLock()
+	defer e.callbackMu.RUnlock()
+	return e.callback
+}
+
+// Call handles "extensionName" callExtension "command" as RVExtension does, returning the response truncated to fit an output buffer of outputSize bytes. An outputSize of 0 disables truncation
+func (e *Extension) Call(input string, outputSize int) string {
+	return e.dispatch(CallFormString, input, nil, outputSize)
+}
+
+// CallArgs handles "extensionName" callExtension ["command", [args]] as RVExtensionArgs does. Args are passed as Arma sends them, so strings must be wrapped in escape quotes
+func (e *Extension) CallArgs(command string, args []string, outputSize int) string {
+	return e.dispatch(CallFormArgs, command, args, outputSize)
+}
+
+// SetVersion sets the version string that will be returned when the extension is first called by Arma. This is a string value and is logged by the game engine to the RPT file
+func (e *Extension) SetVersion(version string) {
+	e.version.Store(&version)
+}
+
+// SetVersion sets the version of the default Extension, see Extension.SetVersion
+func SetVersion(version string) {
+	defaultExtension.SetVersion(version)
+}
+
+// SetExtensionName sets the extension name of callbacks sent by the library, i.e. the results of calls that exceeded their time budget. It defaults to the file name of the loaded module without its extension and "_x64" suffix
+func (e *Extension) SetExtensionName(name string) {
+	e.extensionName.Store(&name)
+}
+
+// name returns the name set with SetExtensionName or the name derived from the module file name
+func (e *Extension) name() string {
+	if name := *e.extensionName.Load(); name != "" {
+		return name
+	}
+	name := filepath.Base(assemblyfinder.GetModulePath())
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.TrimSuffix(name, "_x64")
+}
+
+// SetCaseInsensitive determines whether commands are matched regardless of case
+func (e *Extension) SetCaseInsensitive(caseInsensitive bool) {
+	e.router.SetCaseInsensitive(caseInsensitive)
+}
+
+// SetFallbackHandler sets the Handler that is called when a command is not registered. Without one, Arma receives the error ["error", "NOT_REGISTERED", "command <command> not registered", [], false]
+func (e *Extension) SetFallbackHandler(handler Handler) {
+	e.router.SetFallback(handler)
+}
+
+// Use adds middleware that wraps the handler of every registration, including those of mounted routers and the fallback handler
+func (e *Extension) Use(middleware ...Middleware) {
+	e.router.Use(middleware...)
+}
+
+// Register adds a registration to the extension
+func (e *Extension) Register(r *RVExtensionRegistration) error {
+	if err := e.router.Register(r); err != nil {
+		return err
+	}
+	e.events.publish(Event{
+		Type:    EventRegistration,
+		Message: r.Command,
+	})
+	return nil
+}
+
+// Mount routes every command starting with prefix followed by CommandSeparator to the registrations of sub, i.e. Mount("db", dbRouter) routes "db:query" to the "query" registration of dbRouter
+func (e *Extension) Mount(prefix string, sub *Router) error {
+	if err := e.router.Mount(prefix, sub); err != nil {
+		return err
+	}
+	e.events.publish(Event{
+		Type:    EventRegistration,
+		Message: prefix + CommandSeparator,
+	})
+	return nil
+}
+
+// SetResponseEnvelope determines whether the successful responses of every registration are wrapped as ["ok", response]. Errors are always sent as ["error", code, message, details, retryable]
+func (e *Extension) SetResponseEnvelope(responseEnvelope bool) {
+	e.responseEnvelope.Store(responseEnvelope)
+}
+
+// RegisterErrorChan triggered when an error occurs in the extension, this will send the command and the error to the designated channel. Errors are dropped if the channel is not ready to receive
+//
+// Deprecated: Use Subscribe with EventError and EventPanic, which provides the full call context
+func RegisterErrorChan(
+	channel chan []string,
+) {
+	if defaultExtension.errChanSubscription != nil {
+		defaultExtension.errChanSubscription.Unsubscribe()
+	}
+	subscription := defaultExtension.Subscribe(64, EventError, EventPanic)
+	defaultExtension.errChanSubscription = subscription
+	go func() {
+		for event := range subscription.C {
+			select {
+			case channel <- []string{event.Call.Command, event.Err.Error()}:
+			default:
+				subscription.dropped.Add(1)
+			}
+		}
+	}()
+}
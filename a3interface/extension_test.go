@@ -0,0 +1,115 @@
+package a3interface
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestNewExtension(t *testing.T) {
+	echo := func(prefix string) Handler {
+		return HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(ToArmaHashMap([]interface{}{prefix, req.ArmaContext.SteamID}))
+			return err
+		})
+	}
+	first := NewExtension()
+	second := NewExtension()
+	for _, e := range []*Extension{first, second} {
+		if err := e.Register(NewRegistration("isolated").SetHandler(echo(e.Version()))); err != nil {
+			t.Fatalf("Extension.Register() error = %v", err)
+		}
+	}
+	first.SetVersion("first")
+	first.SetContext(ArmaExtensionContext{SteamID: "1"})
+	if err := first.Register(NewRegistration("isolated")); err == nil {
+		t.Errorf("Extension.Register() error = nil, want duplicate error")
+	}
+
+	tests := []struct {
+		name string
+		e    *Extension
+		want string
+	}{
+		{name: "first", e: first, want: `["No version set", "1"]`},
+		{name: "second", e: second, want: `["No version set", "123456789"]`},
+		{name: "default", e: Default(), want: `["error", "NOT_REGISTERED", "command isolated not registered", [], false]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.Call("isolated", 10240); got != tt.want {
+				t.Errorf("Extension.Call() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := first.Metrics().Commands["isolated"].Calls; got != 1 {
+		t.Errorf("Metrics().Calls = %v, want 1", got)
+	}
+	if first.Version() != "first" || Default().Version() == "first" {
+		t.Errorf("Version() = %v, default %v, want first and unchanged", first.Version(), Default().Version())
+	}
+}
+
+func TestExtension_SetCallback(t *testing.T) {
+	e := NewExtension()
+	var got []string
+	e.SetCallback(func(name string, function string, data string) int {
+		got = append(got, name, function, data)
+		return 0
+	})
+	if err := e.WriteArmaCallback("isolated", "result", "a"); err != nil {
+		t.Fatalf("Extension.WriteArmaCallback() error = %v", err)
+	}
+	want := []string{"isolated", "result", `["a"]`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("callback = %v, want %v", got, want)
+	}
+
+	e.SetCallback(nil)
+	if err := e.WriteArmaCallback("isolated", "result"); err == nil {
+		t.Errorf("Extension.WriteArmaCallback() error = nil, want callback function not set")
+	}
+	if snapshot := e.Metrics(); snapshot.CallbacksSent != 1 || snapshot.CallbacksDropped != 1 {
+		t.Errorf("Metrics() sent %v dropped %v, want 1 and 1", snapshot.CallbacksSent, snapshot.CallbacksDropped)
+	}
+}
+
+func TestExtension_settingsWhileCalled(t *testing.T) {
+	e := NewExtension()
+	err := e.Register(NewRegistration("settings").SetHandler(HandlerFunc(func(req *Request) error {
+		_, span := StartSpan(req.Context(), "settings")
+		span.End()
+		_, err := req.Writer.WriteString(`["ok"]`)
+		return err
+	})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			e.Call("settings", 10240)
+			e.Version()
+			e.Manifest()
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		e.SetVersion(fmt.Sprint(i))
+		e.SetExtensionName(fmt.Sprint(i))
+		e.SetResponseEnvelope(i%2 == 0)
+		e.SetSlowCallThreshold(time.Duration(i) * time.Microsecond)
+		e.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if i%2 == 0 {
+			e.SetSpanExporter(&recordingExporter{})
+			e.SetRecorder(NewRecorder(io.Discard))
+		} else {
+			e.SetSpanExporter(nil)
+			e.SetRecorder(nil)
+		}
+	}
+	<-done
+}
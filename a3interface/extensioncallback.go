@@ -14,33 +14,53 @@ static inline int runExtensionCallback(extensionCallback fnc, char const *name,
 */
 import "C"
 import (
+	"context"
 	"fmt"
 	"strings"
 	"unsafe"
 )
 
-var extensionCallbackFnc C.extensionCallback
-
 // RVExtensionRegisterCallback registers the callback function that will be called when WriteArmaCallback is called
 //
 //export RVExtensionRegisterCallback
 func RVExtensionRegisterCallback(fnc C.extensionCallback) {
-	extensionCallbackFnc = fnc
+	if fnc == nil {
+		defaultExtension.SetCallback(nil)
+		return
+	}
+	defaultExtension.SetCallback(func(name string, function string, data string) int {
+		cName := C.CString(name)
+		defer C.free(unsafe.Pointer(cName))
+		cFunction := C.CString(function)
+		defer C.free(unsafe.Pointer(cFunction))
+		cData := C.CString(data)
+		defer C.free(unsafe.Pointer(cData))
+		return int(C.runExtensionCallback(fnc, cName, cFunction, cData))
+	})
 }
 
-// runExtensionCallback calls the callback function
-func runExtensionCallback(name *C.char, function *C.char, data *C.char) C.int {
-	return C.runExtensionCallback(extensionCallbackFnc, name, function, data)
+// WriteArmaCallback sends a callback with the default Extension, see Extension.WriteArmaCallback
+func WriteArmaCallback(
+	extensionName string,
+	functionName string,
+	data ...string,
+) error {
+	return defaultExtension.WriteArmaCallback(extensionName, functionName, data...)
 }
 
 // WriteArmaCallback takes a function name designation and a series of arguments that it will parse into an array and send to Arma
-func WriteArmaCallback(
+func (e *Extension) WriteArmaCallback(
 	extensionName string,
 	functionName string,
 	data ...string,
 ) (
 	err error,
 ) {
+	return e.writeArmaCallback(context.Background(), extensionName, functionName, data...)
+}
+
+// writeArmaCallback formats data as WriteArmaCallback does and sends it. ctx is the context of the request that sends the callback, if any
+func (e *Extension) writeArmaCallback(ctx context.Context, extensionName string, functionName string, data ...string) error {
 
 	// preprocess data with escape characters
 	for i, v := range data {
@@ -56,18 +76,52 @@ func WriteArmaCallback(
 	}
 	// format the data into a string
 	a3Message := fmt.Sprintf(`[%s]`, strings.Join(data, ","))
+	return e.sendArmaCallback(ctx, extensionName, functionName, a3Message)
+}
 
+// sendArmaCallback sends data to Arma's callback function as is, recording the result. Dropped callbacks are reported with the call of the request ctx belongs to
+func (e *Extension) sendArmaCallback(ctx context.Context, extensionName string, functionName string, data string) error {
 	// check if the callback function is set
-	if extensionCallbackFnc != nil {
-		statusName := C.CString(extensionName)
-		defer C.free(unsafe.Pointer(statusName))
-		statusFunction := C.CString(functionName)
-		defer C.free(unsafe.Pointer(statusFunction))
-		statusParam := C.CString(a3Message)
-		defer C.free(unsafe.Pointer(statusParam))
-		// call the callback function
-		runExtensionCallback(statusName, statusFunction, statusParam)
+	if callback := e.callbackFunc(); callback != nil {
+		// Arma returns a negative value if its callback queue is full
+		result := callback(extensionName, functionName, data)
+		e.record(RecordEntry{Type: RecordCallback, Name: extensionName, Function: functionName, Data: data, Result: result})
+		if result < 0 {
+			e.metrics.recordCallback(false, int64(result))
+			e.publishCallbackDropped(ctx, extensionName, functionName, "callback queue full")
+			return fmt.Errorf("callback queue full")
+		}
+		e.metrics.recordCallback(true, int64(result))
 		return nil
 	}
+	e.metrics.recordCallback(false, 0)
+	e.record(RecordEntry{Type: RecordCallback, Name: extensionName, Function: functionName, Data: data})
+	e.publishCallbackDropped(ctx, extensionName, functionName, "callback function not set")
 	return fmt.Errorf("callback function not set")
 }
+
+// WriteArmaCallbackContext sends a callback like WriteArmaCallback, recording it as a child span of the span in ctx, i.e. the request's context in a background handler. If the callback is dropped, EventCallbackDropped holds the call of the request
+func (e *Extension) WriteArmaCallbackContext(
+	ctx context.Context,
+	extensionName string,
+	functionName string,
+	data ...string,
+) error {
+	ctx, span := e.StartSpan(ctx, "callback "+functionName)
+	span.SetAttribute("a3go.callback.extension", extensionName)
+	span.SetAttribute("a3go.callback.function", functionName)
+	err := e.writeArmaCallback(ctx, extensionName, functionName, data...)
+	span.SetError(err)
+	span.End()
+	return err
+}
+
+// publishCallbackDropped publishes EventCallbackDropped for a callback that did not reach Arma, with the call of the request ctx belongs to
+func (e *Extension) publishCallbackDropped(ctx context.Context, extensionName string, functionName string, reason string) {
+	e.events.publish(Event{
+		Type:    EventCallbackDropped,
+		Call:    callFromContext(ctx),
+		Err:     fmt.Errorf("%s", reason),
+		Message: fmt.Sprintf("%s %s: %s", extensionName, functionName, reason),
+	})
+}
@@ -0,0 +1,172 @@
+package a3interface
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CallForm describes which exported function Arma used to reach the extension
+type CallForm int
+
+const (
+	// CallFormString is used for "extension" callExtension "command|data" (RVExtension)
+	CallFormString CallForm = iota
+	// CallFormArgs is used for "extension" callExtension ["command", ["data"]] (RVExtensionArgs)
+	CallFormArgs
+)
+
+// String returns the name of the exported function used for this call form
+func (f CallForm) String() string {
+	switch f {
+	case CallFormString:
+		return "RVExtension"
+	case CallFormArgs:
+		return "RVExtensionArgs"
+	default:
+		return fmt.Sprintf("CallForm(%d)", int(f))
+	}
+}
+
+// MarshalText encodes the call form as the name of its exported function
+func (f CallForm) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText decodes a call form from the name of its exported function
+func (f *CallForm) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "RVExtension":
+		*f = CallFormString
+	case "RVExtensionArgs":
+		*f = CallFormArgs
+	default:
+		return fmt.Errorf("unknown call form %s", text)
+	}
+	return nil
+}
+
+// Request holds everything known about a single call from Arma
+type Request struct {
+	// Command is the command Arma called
+	Command string
+	// Pattern is the Command of the registration that matched this call, with the prefixes of the routers it is mounted under, i.e. "db:query". It differs from Command when the registration was matched through an alias or a pattern
+	Pattern string
+	// Params are the segments captured by the matched pattern, see Router
+	Params map[string]string
+	// Args are the arguments passed with the command. For RVExtension these are the pipe-delimited values after the command, for RVExtensionArgs they are the array elements with escape quotes removed
+	Args []string
+	// RawArgs are the arguments exactly as received from Arma
+	RawArgs []string
+	// Values are the arguments parsed into Go values by the registration's ArgSchema. It is nil if the registration has no schema
+	Values []interface{}
+	// RawInput is the unprocessed input string received from Arma. For RVExtension this is the full "command|data" string, for RVExtensionArgs it is the command element
+	RawInput string
+	// Form is the call form Arma used
+	Form CallForm
+	// ArmaContext is the context Arma sent just before this call
+	ArmaContext ArmaExtensionContext
+	// OutputSize is the size of the buffer Arma provided for the synchronous response, including the null terminator
+	OutputSize int
+	// Writer collects the response that will be sent to Arma for synchronous calls
+	Writer ResponseWriter
+
+	ctx context.Context
+	// ext is the Extension handling the call
+	ext *Extension
+	// metricsKey is the command the call is recorded under, see Metrics
+	metricsKey string
+}
+
+// Context returns the request's context. It is never nil
+func (r *Request) Context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
+}
+
+// Extension returns the Extension handling the call, or the default Extension if the request was not created by one
+func (r *Request) Extension() *Extension {
+	if r.ext != nil {
+		return r.ext
+	}
+	return defaultExtension
+}
+
+// Param returns the segment captured by the matched pattern under name, or an empty string
+func (r *Request) Param(name string) string {
+	return r.Params[name]
+}
+
+// WithContext returns a shallow copy of the request with its context changed to ctx
+func (r *Request) WithContext(ctx context.Context) *Request {
+	if ctx == nil {
+		panic("nil context")
+	}
+	r2 := new(Request)
+	*r2 = *r
+	r2.ctx = ctx
+	return r2
+}
+
+// ResponseWriter is used by a Handler to build the response that is returned to Arma
+type ResponseWriter interface {
+	Write(p []byte) (int, error)
+	WriteString(s string) (int, error)
+	// Len returns the number of bytes written so far
+	Len() int
+}
+
+// responseBuffer is the ResponseWriter used by the dispatcher
+type responseBuffer struct {
+	strings.Builder
+}
+
+// Handler responds to a call from Arma. Anything written to req.Writer is returned to Arma for synchronous calls. A returned error is reported in place of the response
+type Handler interface {
+	ServeRV(req *Request) error
+}
+
+// HandlerFunc allows the use of an ordinary function as a Handler
+type HandlerFunc func(req *Request) error
+
+// ServeRV calls f(req)
+func (f HandlerFunc) ServeRV(req *Request) error {
+	return f(req)
+}
+
+// legacyHandler adapts the Function and ArgsFunction fields of a registration to the Handler interface
+type legacyHandler struct {
+	function func(
+		ctx ArmaExtensionContext,
+		data string) (string, error)
+	argsFunction func(
+		ctx ArmaExtensionContext,
+		command string,
+		args []string) (string, error)
+}
+
+func (h legacyHandler) ServeRV(req *Request) error {
+	var (
+		response string
+		err      error
+	)
+	switch req.Form {
+	case CallFormArgs:
+		if h.argsFunction == nil {
+			return Errorf(ErrCodeHandlerNotSet, "RVExtensionArgs function not set for command %s", req.Command)
+		}
+		response, err = h.argsFunction(req.ArmaContext, req.Command, req.Args)
+	default:
+		if h.function == nil {
+			return Errorf(ErrCodeHandlerNotSet, "RVExtension function not set for command %s", req.Command)
+		}
+		response, err = h.function(req.ArmaContext, req.RawInput)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = req.Writer.WriteString(response)
+	return err
+}
@@ -0,0 +1,257 @@
+package a3interface
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentErrorsSize is the number of errors kept for the errors introspection command
+const recentErrorsSize = 32
+
+// recentError is an error kept for the errors introspection command
+type recentError struct {
+	time    time.Time
+	command string
+	code    string
+	message string
+}
+
+// errorRing keeps the most recent errors, oldest first
+type errorRing struct {
+	mu     sync.Mutex
+	errors []recentError
+	next   int
+}
+
+func (r *errorRing) add(e recentError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errors) < recentErrorsSize {
+		r.errors = append(r.errors, e)
+		return
+	}
+	r.errors[r.next] = e
+	r.next = (r.next + 1) % recentErrorsSize
+}
+
+func (r *errorRing) list() []recentError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(append([]recentError(nil), r.errors[r.next:]...), r.errors[:r.next]...)
+}
+
+// healthCheck is a check added with RegisterHealthCheck
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+// healthChecks are run by the health introspection command
+type healthChecks struct {
+	mu     sync.RWMutex
+	checks []healthCheck
+}
+
+// RegisterHealthCheck adds a check to the health introspection command. A check returning an error marks the extension as degraded. A check registered again under the same name replaces the previous one
+func (e *Extension) RegisterHealthCheck(name string, check func() error) {
+	h := e.healthChecks
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for index, c := range h.checks {
+		if c.name == name {
+			h.checks[index].check = check
+			return
+		}
+	}
+	h.checks = append(h.checks, healthCheck{name: name, check: check})
+}
+
+// Registrations returns a copy of every registration of this router, including those of mounted routers with the mount prefix added to their command and aliases
+func (rt *Router) Registrations() []RVExtensionRegistration {
+	var registrations []RVExtensionRegistration
+	for _, m := range rt.matches(false) {
+		reg := *m.registration
+		reg.Command, reg.Aliases = m.command(), m.aliases()
+		registrations = append(registrations, reg)
+	}
+	return registrations
+}
+
+// matches returns every registration of this router and its mounted routers as lookup matches them, with their mount prefix and case sensitivity
+func (rt *Router) matches(caseInsensitive bool) []routeMatch {
+	caseInsensitive = caseInsensitive || rt.caseInsensitive
+	var matches []routeMatch
+	for _, reg := range rt.routes {
+		matches = append(matches, routeMatch{registration: reg, caseInsensitive: caseInsensitive})
+	}
+	for _, p := range rt.patterns {
+		matches = append(matches, routeMatch{registration: p.registration, caseInsensitive: caseInsensitive})
+	}
+	for _, m := range rt.mounts {
+		for _, sub := range m.router.matches(caseInsensitive) {
+			sub.prefix = m.prefix + CommandSeparator + sub.prefix
+			matches = append(matches, sub)
+		}
+	}
+	return matches
+}
+
+// Registrations returns a copy of every registration of the extension, see Router.Registrations
+func (e *Extension) Registrations() []RVExtensionRegistration {
+	return e.router.Registrations()
+}
+
+// RegisterIntrospectionCommands registers synchronous commands under prefix that describe the running extension, so missions can detect its capabilities at runtime. With the prefix "a3go", these are:
+//
+//   - "a3go:commands" returns [[command, [["mode", "sync"|"async"], ["aliases", [...]], ["args", [...]], ["variadic", bool]]], ...], where each arg is [["name", name], ["type", type], ["optional", bool]] with ["min", n] and ["max", n] if a range is set
+//   - "a3go:version" returns [["version", version], ["goVersion", version], ["os", os], ["arch", arch], ...] followed by the module and VCS information embedded in the build
+//   - "a3go:health" returns [["status", "ok"|"degraded"], ["checks", [[name, ok, message], ...]], ["callbackRegistered", bool], ["goroutines", n]]
+//   - "a3go:uptime" returns [["seconds", n], ["startedAt", "2006-01-02T15:04:05Z"]]
+//   - "a3go:errors" returns the most recent errors, oldest first, as [[["time", "2006-01-02T15:04:05Z"], ["command", command], ["code", code], ["message", message]], ...]
+//
+// Each inner array can be passed to createHashMapFromArray
+func (e *Extension) RegisterIntrospectionCommands(prefix string) error {
+	router := NewRouter()
+	commands := []struct {
+		command     string
+		description string
+		response    string
+		describe    func() interface{}
+	}{
+		{"commands", "Lists the registered commands.", `[[command, [["mode", "sync"|"async"], ["aliases", [...]], ["args", [...]], ["variadic", bool]]], ...]`, e.introspectCommands},
+		{"version", "Returns the version of the extension and the Go build.", `[["version", version], ["goVersion", version], ["os", os], ["arch", arch], ...]`, e.introspectVersion},
+		{"health", "Runs the health checks of the extension.", `[["status", "ok"|"degraded"], ["checks", [[name, ok, message], ...]], ["callbackRegistered", bool], ["goroutines", n]]`, e.introspectHealth},
+		{"uptime", "Returns how long the extension has been loaded.", `[["seconds", n], ["startedAt", "2006-01-02T15:04:05Z"]]`, e.introspectUptime},
+		{"errors", "Returns the most recent errors, oldest first.", `[[["time", "2006-01-02T15:04:05Z"], ["command", command], ["code", code], ["message", message]], ...]`, e.introspectErrors},
+	}
+	for _, c := range commands {
+		describe := c.describe
+		err := NewRegistration(c.command).
+			SetDescription(c.description).
+			SetResponse(c.response).
+			SetHandler(HandlerFunc(func(req *Request) error {
+				_, err := req.Writer.WriteString(ToArmaHashMap(describe()))
+				return err
+			})).
+			RegisterTo(router)
+		if err != nil {
+			return err
+		}
+	}
+	return e.Mount(prefix, router)
+}
+
+func (e *Extension) introspectCommands() interface{} {
+	registrations := e.Registrations()
+	entries := make([]interface{}, 0, len(registrations))
+	for _, reg := range registrations {
+		mode := "sync"
+		if reg.RunInBackground {
+			mode = "async"
+		}
+		aliases := make([]interface{}, 0, len(reg.Aliases))
+		for _, alias := range reg.Aliases {
+			aliases = append(aliases, alias)
+		}
+		args := []interface{}{}
+		variadic := false
+		if reg.ArgSchema != nil {
+			variadic = reg.ArgSchema.Variadic
+			for _, spec := range reg.ArgSchema.Args {
+				arg := []interface{}{
+					[]interface{}{"name", spec.Name},
+					[]interface{}{"type", string(spec.Type)},
+					[]interface{}{"optional", spec.Optional},
+				}
+				if spec.Min != nil {
+					arg = append(arg, []interface{}{"min", *spec.Min})
+				}
+				if spec.Max != nil {
+					arg = append(arg, []interface{}{"max", *spec.Max})
+				}
+				args = append(args, arg)
+			}
+		}
+		entries = append(entries, []interface{}{
+			reg.Command,
+			[]interface{}{
+				[]interface{}{"mode", mode},
+				[]interface{}{"aliases", aliases},
+				[]interface{}{"args", args},
+				[]interface{}{"variadic", variadic},
+			},
+		})
+	}
+	return entries
+}
+
+func (e *Extension) introspectVersion() interface{} {
+	entries := []interface{}{
+		[]interface{}{"version", e.Version()},
+		[]interface{}{"goVersion", runtime.Version()},
+		[]interface{}{"os", runtime.GOOS},
+		[]interface{}{"arch", runtime.GOARCH},
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return entries
+	}
+	entries = append(entries,
+		[]interface{}{"module", info.Main.Path},
+		[]interface{}{"moduleVersion", info.Main.Version},
+	)
+	for _, setting := range info.Settings {
+		if strings.HasPrefix(setting.Key, "vcs") {
+			entries = append(entries, []interface{}{setting.Key, setting.Value})
+		}
+	}
+	return entries
+}
+
+func (e *Extension) introspectHealth() interface{} {
+	h := e.healthChecks
+	h.mu.RLock()
+	checks := append([]healthCheck(nil), h.checks...)
+	h.mu.RUnlock()
+
+	status := "ok"
+	results := make([]interface{}, 0, len(checks))
+	for _, c := range checks {
+		ok, message := true, ""
+		if err := c.check(); err != nil {
+			status = "degraded"
+			ok, message = false, err.Error()
+		}
+		results = append(results, []interface{}{c.name, ok, message})
+	}
+	return []interface{}{
+		[]interface{}{"status", status},
+		[]interface{}{"checks", results},
+		[]interface{}{"callbackRegistered", e.callbackFunc() != nil},
+		[]interface{}{"goroutines", runtime.NumGoroutine()},
+	}
+}
+
+func (e *Extension) introspectUptime() interface{} {
+	return []interface{}{
+		[]interface{}{"seconds", time.Since(e.startTime).Truncate(time.Millisecond).Seconds()},
+		[]interface{}{"startedAt", e.startTime.UTC().Format(time.RFC3339)},
+	}
+}
+
+func (e *Extension) introspectErrors() interface{} {
+	recent := e.recentErrors.list()
+	entries := make([]interface{}, 0, len(recent))
+	for _, re := range recent {
+		entries = append(entries, []interface{}{
+			[]interface{}{"time", re.time.UTC().Format(time.RFC3339)},
+			[]interface{}{"command", re.command},
+			[]interface{}{"code", re.code},
+			[]interface{}{"message", re.message},
+		})
+	}
+	return entries
+}
@@ -0,0 +1,91 @@
+package a3interface
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterIntrospectionCommands(t *testing.T) {
+	e := NewExtension()
+	if err := e.RegisterIntrospectionCommands("introspect"); err != nil {
+		t.Fatalf("RegisterIntrospectionCommands() error = %v", err)
+	}
+	err := e.Register(NewRegistration("introspectSave").
+		SetAliases("introspectStore").
+		SetRunInBackground(true).
+		SetArgSchema(NewArgSchema().Arg("id", ArgNumber).Range(1, 10)).
+		SetHandler(HandlerFunc(func(req *Request) error { return nil })))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	err = e.Register(NewRegistration("introspectFail").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			return NewError("DB_BUSY", "database is busy")
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	e.RegisterHealthCheck("database", func() error { return errors.New("connection refused") })
+
+	e.dispatch(CallFormString, "introspectFail", nil, 10240)
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{
+			name:    "commands",
+			command: "introspect:commands",
+			want: []string{
+				`["introspectSave", [["mode", "async"], ["aliases", ["introspectStore"]], ["args", [[["name", "id"], ["type", "number"], ["optional", false], ["min", 1], ["max", 10]]]], ["variadic", false]]]`,
+				`["introspect:uptime", [["mode", "sync"], ["aliases", []], ["args", []], ["variadic", false]]]`,
+			},
+		},
+		{
+			name:    "version",
+			command: "introspect:version",
+			want:    []string{`["version", "`, `["goVersion", "go`, `["os", "`},
+		},
+		{
+			name:    "health",
+			command: "introspect:health",
+			want:    []string{`["status", "degraded"]`, `["database", false, "connection refused"]`},
+		},
+		{
+			name:    "uptime",
+			command: "introspect:uptime",
+			want:    []string{`["seconds", `, `["startedAt", "`},
+		},
+		{
+			name:    "errors",
+			command: "introspect:errors",
+			want:    []string{`["command", "introspectFail"], ["code", "DB_BUSY"], ["message", "database is busy"]`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.dispatch(CallFormString, tt.command, nil, 10240)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("dispatch() = %v, want it to contain %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_errorRing_list(t *testing.T) {
+	ring := &errorRing{}
+	for i := 0; i < recentErrorsSize+2; i++ {
+		ring.add(recentError{code: string(rune('a' + i%26))})
+	}
+	got := ring.list()
+	if len(got) != recentErrorsSize {
+		t.Fatalf("len(list()) = %v, want %v", len(got), recentErrorsSize)
+	}
+	if got[0].code != "c" || got[len(got)-1].code != string(rune('a'+(recentErrorsSize+1)%26)) {
+		t.Errorf("list() = %v ... %v, want oldest first", got[0].code, got[len(got)-1].code)
+	}
+}
@@ -0,0 +1,96 @@
+package a3interface
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+)
+
+// SetLogger sets the logger used by the extension. By default only warnings and errors are written to stderr. Pass nil to disable logging entirely
+func (e *Extension) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(discardHandler{})
+	}
+	e.logger.Store(logger)
+}
+
+// Logger returns the logger used by the extension, so extensions can log to the same sinks
+func (e *Extension) Logger() *slog.Logger {
+	return e.logger.Load()
+}
+
+// defaultLogger writes warnings and errors to stderr
+func defaultLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	}))
+}
+
+// Logger returns the library logger with attributes describing this call. The SteamID of the caller is not included
+func (r *Request) Logger() *slog.Logger {
+	return r.Extension().Logger().With(r.logAttrs()...)
+}
+
+// logAttrs returns the call-scoped attributes used for log records
+func (r *Request) logAttrs() []interface{} {
+	return []interface{}{
+		slog.String("command", r.Command),
+		slog.String("form", r.Form.String()),
+		slog.String("mission", r.ArmaContext.MissionNameSource),
+		slog.String("server", r.ArmaContext.ServerName),
+	}
+}
+
+// discardHandler is a slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// NewMultiLogHandler returns a slog.Handler that passes each record to every handler that is enabled for its level
+func NewMultiLogHandler(handlers ...slog.Handler) slog.Handler {
+	return multiHandler(handlers)
+}
+
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if !h.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,172 @@
+package a3interface
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/indig0fox/a3go/assemblyfinder"
+)
+
+// RotatingFile is an io.Writer that appends to a file and rotates it once it grows beyond a maximum size, keeping a number of older files as name.1, name.2 and so on
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile opens or creates the file at path for appending. Once it exceeds maxBytes it is rotated, keeping up to maxBackups older files
+func NewRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewModuleLogFile opens a RotatingFile with the given name in the directory of the loaded DLL or SO file
+func NewModuleLogFile(name string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	return NewRotatingFile(
+		filepath.Join(filepath.Dir(assemblyfinder.GetModulePath()), name),
+		maxBytes,
+		maxBackups,
+	)
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %s", err.Error())
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error reading log file: %s", err.Error())
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would exceed the maximum size
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts existing backups up by one, moves the current file to the first backup and opens a new file
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	if r.maxBackups < 1 {
+		os.Remove(r.path)
+		return r.open()
+	}
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close closes the file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// callbackLogHandler forwards log records to Arma as callbacks
+type callbackLogHandler struct {
+	extensionName string
+	functionName  string
+	level         slog.Leveler
+	attrs         []slog.Attr
+	group         string
+}
+
+// NewCallbackLogHandler returns a slog.Handler that sends records at or above level to Arma using WriteArmaCallback, as [level, message, attributes]. In SQF, handle the callback with the ExtensionCallback mission event handler and pass it to diag_log
+func NewCallbackLogHandler(extensionName string, functionName string, level slog.Leveler) slog.Handler {
+	if level == nil {
+		level = slog.LevelWarn
+	}
+	return &callbackLogHandler{
+		extensionName: extensionName,
+		functionName:  functionName,
+		level:         level,
+	}
+}
+
+func (h *callbackLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *callbackLogHandler) Handle(_ context.Context, record slog.Record) error {
+	var attrs []string
+	appendAttr := func(a slog.Attr) bool {
+		key := a.Key
+		if h.group != "" {
+			key = h.group + "." + key
+		}
+		attrs = append(attrs, fmt.Sprintf("%s=%s", key, a.Value.String()))
+		return true
+	}
+	for _, a := range h.attrs {
+		appendAttr(a)
+	}
+	record.Attrs(appendAttr)
+	return WriteArmaCallback(
+		h.extensionName,
+		h.functionName,
+		record.Level.String(),
+		record.Message,
+		strings.Join(attrs, " "),
+	)
+}
+
+func (h *callbackLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &h2
+}
+
+func (h *callbackLogHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	if h2.group != "" {
+		name = h2.group + "." + name
+	}
+	h2.group = name
+	return &h2
+}
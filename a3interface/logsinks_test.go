@@ -0,0 +1,47 @@
+package a3interface
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	file, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("RotatingFile.Write() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "current", path: path, want: "fourth\n"},
+		{name: "backup 1", path: path + ".1", want: "third\n"},
+		{name: "backup 2", path: path + ".2", want: "second\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatalf("os.ReadFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backup 3 exists, want at most 2 backups")
+	}
+}
@@ -0,0 +1,117 @@
+package a3interface
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// CallbackSpec describes a callback a command sends with WriteArmaCallback
+type CallbackSpec struct {
+	// Function is the function name the callback is sent with, the second argument of the ExtensionCallback event handler
+	Function string `json:"function"`
+	// Description describes the data of the callback
+	Description string `json:"description,omitempty"`
+}
+
+// ExtensionManifest describes every command of an extension for mission makers and tools that don't read Go. It is marshalled to JSON by WriteManifest
+type ExtensionManifest struct {
+	// Extension is the name the extension is called by
+	Extension string `json:"extension"`
+	// Version is the version set with SetVersion
+	Version string `json:"version,omitempty"`
+	// Commands are sorted by command
+	Commands []CommandManifest `json:"commands"`
+}
+
+// CommandManifest describes a command in an ExtensionManifest
+type CommandManifest struct {
+	// Command may be a pattern, see Router
+	Command string   `json:"command"`
+	Aliases []string `json:"aliases,omitempty"`
+	// Mode is "sync" if Arma receives the handler's response, or "async" if it receives DefaultResponse and the handler runs in the background
+	Mode        string `json:"mode"`
+	Description string `json:"description,omitempty"`
+	// Args is nil if the command has no ArgSchema and its arguments are not checked
+	Args     *ArgSchema `json:"args,omitempty"`
+	Response string     `json:"response,omitempty"`
+	// ResponseEnvelope is true if successful responses are wrapped as ["ok", response], set on the registration or the extension
+	ResponseEnvelope bool `json:"responseEnvelope,omitempty"`
+	// DefaultResponse is the response of async commands
+	DefaultResponse string `json:"defaultResponse,omitempty"`
+	// TimeBudget is the TimeBudget of sync commands, i.e. "50ms"
+	TimeBudget string         `json:"timeBudget,omitempty"`
+	Callbacks  []CallbackSpec `json:"callbacks,omitempty"`
+	// Access are the policies that apply to the command, set on the registration or loaded with LoadAccessPolicies. Every one must allow a call
+	Access  []*AccessPolicy `json:"access,omitempty"`
+	Example string          `json:"example,omitempty"`
+}
+
+// Manifest returns an ExtensionManifest of every registration of the extension, with the mount prefixes added as Extension.Registrations does
+func (e *Extension) Manifest() ExtensionManifest {
+	matches := e.router.matches(false)
+	manifest := ExtensionManifest{
+		Extension: e.name(),
+		Version:   e.Version(),
+		Commands:  make([]CommandManifest, 0, len(matches)),
+	}
+	for _, m := range matches {
+		reg := m.registration
+		command := CommandManifest{
+			Command:          m.command(),
+			Aliases:          m.aliases(),
+			Mode:             "sync",
+			Description:      reg.Description,
+			Args:             reg.ArgSchema,
+			Response:         reg.Response,
+			ResponseEnvelope: e.responseEnvelope.Load() || reg.ResponseEnvelope,
+			Callbacks:        append([]CallbackSpec(nil), reg.Callbacks...),
+			Example:          reg.Example,
+		}
+		if reg.RunInBackground {
+			command.Mode = "async"
+			command.DefaultResponse = reg.DefaultResponse
+		} else if reg.TimeBudget > 0 {
+			command.TimeBudget = reg.TimeBudget.String()
+			command.Callbacks = addPendingCallback(command.Callbacks)
+		}
+		policies := e.accessPolicies.matching(m.names(), m.caseInsensitive)
+		if reg.AccessPolicy != nil {
+			policies = append(policies, reg.AccessPolicy)
+		}
+		for _, policy := range policies {
+			command.Access = append(command.Access, &AccessPolicy{
+				ServerOnly:         policy.ServerOnly,
+				SteamIDs:           policy.SteamIDs,
+				AdminsFile:         policy.AdminsFile,
+				Missions:           policy.Missions,
+				DenyRemoteExecuted: policy.DenyRemoteExecuted,
+			})
+		}
+		manifest.Commands = append(manifest.Commands, command)
+	}
+	sort.Slice(manifest.Commands, func(i, j int) bool {
+		return manifest.Commands[i].Command < manifest.Commands[j].Command
+	})
+	return manifest
+}
+
+// WriteManifest writes the ExtensionManifest of the extension as indented JSON
+func (e *Extension) WriteManifest(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e.Manifest())
+}
+
+// addPendingCallback adds the callback of calls that exceed their time budget, unless it is declared already
+func addPendingCallback(callbacks []CallbackSpec) []CallbackSpec {
+	for _, callback := range callbacks {
+		if callback.Function == PendingCallbackFunction {
+			return callbacks
+		}
+	}
+	return append(callbacks, CallbackSpec{
+		Function:    PendingCallbackFunction,
+		Description: "[jobID, response] of calls that exceeded their time budget",
+	})
+}
@@ -0,0 +1,90 @@
+package a3interface
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtension_Manifest(t *testing.T) {
+	e := NewExtension()
+	e.SetVersion("1.2.3")
+	e.SetExtensionName("MyExtension")
+	policies := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(policies, []byte(`{"commands": {"admin:*": {"serverOnly": true}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.LoadAccessPolicies(policies); err != nil {
+		t.Fatalf("LoadAccessPolicies() error = %v", err)
+	}
+	noop := HandlerFunc(func(req *Request) error { return nil })
+	e.Register(NewRegistration("save").
+		SetDescription("Saves a loadout").
+		SetExample(`"MyExtension" callExtension ["save", [[1, 2]]]`).
+		SetResponse("[saved]").
+		SetTimeBudget(50 * time.Millisecond).
+		SetAccessPolicy(&AccessPolicy{DenyRemoteExecuted: true}).
+		SetArgSchema(NewArgSchema().Arg("loadout", ArgArray)).
+		SetHandler(noop))
+	e.Register(NewRegistration("load").
+		SetRunInBackground(true).
+		SetDefaultResponse(`["loading"]`).
+		SetCallbacks(CallbackSpec{Function: "loaded", Description: "[id, loadout]"}).
+		SetHandler(noop))
+	admin := NewRouter()
+	NewRegistration("kick").SetAliases("remove").SetResponseEnvelope(true).SetHandler(noop).RegisterTo(admin)
+	e.Mount("admin", admin)
+
+	want := ExtensionManifest{
+		Extension: "MyExtension",
+		Version:   "1.2.3",
+		Commands: []CommandManifest{
+			{
+				Command:          "admin:kick",
+				Aliases:          []string{"admin:remove"},
+				Mode:             "sync",
+				ResponseEnvelope: true,
+				Access:           []*AccessPolicy{{ServerOnly: true}},
+			},
+			{
+				Command:         "load",
+				Mode:            "async",
+				DefaultResponse: `["loading"]`,
+				Callbacks:       []CallbackSpec{{Function: "loaded", Description: "[id, loadout]"}},
+			},
+			{
+				Command:     "save",
+				Mode:        "sync",
+				Description: "Saves a loadout",
+				Args:        NewArgSchema().Arg("loadout", ArgArray),
+				Response:    "[saved]",
+				TimeBudget:  "50ms",
+				Callbacks:   []CallbackSpec{{Function: PendingCallbackFunction, Description: "[jobID, response] of calls that exceeded their time budget"}},
+				Access:      []*AccessPolicy{{DenyRemoteExecuted: true}},
+				Example:     `"MyExtension" callExtension ["save", [[1, 2]]]`,
+			},
+		},
+	}
+	got := e.Manifest()
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("Manifest() = %s, want %s", gotJSON, wantJSON)
+	}
+
+	var buf bytes.Buffer
+	if err := e.WriteManifest(&buf); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+	var decoded ExtensionManifest
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteManifest() wrote invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("WriteManifest() = %s, want it to decode to the manifest", buf.String())
+	}
+}
@@ -0,0 +1,414 @@
+package a3interface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// metricsKeyUnregistered is the command calls to unregistered commands are recorded under
+	metricsKeyUnregistered = "_unregistered"
+	// metricsKeyFallback is the command calls handled by a fallback handler are recorded under
+	metricsKeyFallback = "_fallback"
+)
+
+// latencyBuckets are the upper bounds in seconds of the call duration histograms
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramSnapshot is a point-in-time copy of a latency histogram
+type HistogramSnapshot struct {
+	// Buckets are the upper bounds of each bucket in seconds
+	Buckets []float64
+	// Counts are the cumulative number of observations less than or equal to each bucket
+	Counts []uint64
+	// Count is the total number of observations
+	Count uint64
+	// Sum is the sum of all observations in seconds
+	Sum float64
+}
+
+// Mean returns the mean observation, or 0 if there are none
+func (h HistogramSnapshot) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return time.Duration(h.Sum / float64(h.Count) * float64(time.Second))
+}
+
+// CommandMetrics holds the metrics recorded for a single registration. Calls to unregistered commands are recorded under "_unregistered" and calls handled by a fallback handler under "_fallback"
+type CommandMetrics struct {
+	Calls       uint64
+	Errors      uint64
+	Panics      uint64
+	Timeouts    uint64
+	Truncations uint64
+	// CacheHits is the number of calls answered from the response cache
+	CacheHits uint64
+	// Coalesced is the number of calls that shared the response of an identical call already running
+	Coalesced uint64
+	// BytesIn is the size of the command and arguments received from Arma
+	BytesIn uint64
+	// BytesOut is the size of the responses returned to Arma
+	BytesOut uint64
+	// SyncLatency is the duration of handlers Arma waited for
+	SyncLatency HistogramSnapshot
+	// AsyncLatency is the duration of handlers that ran in the background
+	AsyncLatency HistogramSnapshot
+}
+
+// MetricsSnapshot is a point-in-time copy of every metric recorded by the dispatcher
+type MetricsSnapshot struct {
+	// Commands are keyed by the Command of the registration, so calls matched through aliases and patterns are recorded together
+	Commands map[string]CommandMetrics
+	// CallbacksSent is the number of callbacks delivered to Arma
+	CallbacksSent uint64
+	// CallbacksDropped is the number of callbacks that could not be delivered
+	CallbacksDropped uint64
+	// CallbackQueue is the last value returned by Arma's callback function, which is negative when its queue is full
+	CallbackQueue int64
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(latencyBuckets))}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (h *histogram) snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return HistogramSnapshot{
+		Buckets: append([]float64(nil), latencyBuckets...),
+		Counts:  append([]uint64(nil), h.counts...),
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+}
+
+type commandMetrics struct {
+	calls        atomic.Uint64
+	errors       atomic.Uint64
+	panics       atomic.Uint64
+	timeouts     atomic.Uint64
+	truncations  atomic.Uint64
+	cacheHits    atomic.Uint64
+	coalesced    atomic.Uint64
+	bytesIn      atomic.Uint64
+	bytesOut     atomic.Uint64
+	syncLatency  *histogram
+	asyncLatency *histogram
+}
+
+// metricsRegistry holds the metrics recorded by the dispatcher
+type metricsRegistry struct {
+	mu               sync.RWMutex
+	commands         map[string]*commandMetrics
+	callbacksSent    atomic.Uint64
+	callbacksDropped atomic.Uint64
+	callbackQueue    atomic.Int64
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{
+		commands: make(map[string]*commandMetrics),
+	}
+}
+
+func (m *metricsRegistry) command(key string) *commandMetrics {
+	m.mu.RLock()
+	c, ok := m.commands[key]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.commands[key]; !ok {
+		c = &commandMetrics{
+			syncLatency:  newHistogram(),
+			asyncLatency: newHistogram(),
+		}
+		m.commands[key] = c
+	}
+	return c
+}
+
+// recordCall records a call once its response to Arma is known
+func (m *metricsRegistry) recordCall(req *Request, bytesOut int, truncated bool) {
+	c := m.command(req.metricsKey)
+	c.calls.Add(1)
+	// the arguments of RVExtension calls are part of the raw input
+	bytesIn := len(req.RawInput)
+	if req.Form == CallFormArgs {
+		for _, arg := range req.RawArgs {
+			bytesIn += len(arg)
+		}
+	}
+	c.bytesIn.Add(uint64(bytesIn))
+	c.bytesOut.Add(uint64(bytesOut))
+	if truncated {
+		c.truncations.Add(1)
+	}
+}
+
+// recordDuration records the time a handler took
+func (m *metricsRegistry) recordDuration(req *Request, background bool, duration time.Duration) {
+	c := m.command(req.metricsKey)
+	if background {
+		c.asyncLatency.observe(duration)
+		return
+	}
+	c.syncLatency.observe(duration)
+}
+
+// recordError records a failed call
+func (m *metricsRegistry) recordError(req *Request, err error) {
+	c := m.command(req.metricsKey)
+	c.errors.Add(1)
+	if AsError(err).Code == ErrCodePanic {
+		c.panics.Add(1)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.timeouts.Add(1)
+	}
+}
+
+// recordTimeout records a call that exceeded its time budget
+func (m *metricsRegistry) recordTimeout(req *Request) {
+	m.command(req.metricsKey).timeouts.Add(1)
+}
+
+// recordCacheHit records a call answered from the response cache
+func (m *metricsRegistry) recordCacheHit(req *Request) {
+	m.command(req.metricsKey).cacheHits.Add(1)
+}
+
+// recordCoalesced records a call that shared the response of an identical call
+func (m *metricsRegistry) recordCoalesced(req *Request) {
+	m.command(req.metricsKey).coalesced.Add(1)
+}
+
+// recordCallback records the result of a callback to Arma
+func (m *metricsRegistry) recordCallback(delivered bool, result int64) {
+	m.callbackQueue.Store(result)
+	if delivered {
+		m.callbacksSent.Add(1)
+		return
+	}
+	m.callbacksDropped.Add(1)
+}
+
+func (m *metricsRegistry) snapshot() MetricsSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snapshot := MetricsSnapshot{
+		Commands:         make(map[string]CommandMetrics, len(m.commands)),
+		CallbacksSent:    m.callbacksSent.Load(),
+		CallbacksDropped: m.callbacksDropped.Load(),
+		CallbackQueue:    m.callbackQueue.Load(),
+	}
+	for key, c := range m.commands {
+		snapshot.Commands[key] = CommandMetrics{
+			Calls:        c.calls.Load(),
+			Errors:       c.errors.Load(),
+			Panics:       c.panics.Load(),
+			Timeouts:     c.timeouts.Load(),
+			Truncations:  c.truncations.Load(),
+			CacheHits:    c.cacheHits.Load(),
+			Coalesced:    c.coalesced.Load(),
+			BytesIn:      c.bytesIn.Load(),
+			BytesOut:     c.bytesOut.Load(),
+			SyncLatency:  c.syncLatency.snapshot(),
+			AsyncLatency: c.asyncLatency.snapshot(),
+		}
+	}
+	return snapshot
+}
+
+// Metrics returns a snapshot of the metrics recorded by the dispatcher
+func (e *Extension) Metrics() MetricsSnapshot {
+	return e.metrics.snapshot()
+}
+
+// commands returns the commands of the snapshot in sorted order
+func (s MetricsSnapshot) commands() []string {
+	commands := make([]string, 0, len(s.Commands))
+	for command := range s.Commands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// WritePrometheus writes the snapshot in the Prometheus text exposition format
+func (s MetricsSnapshot) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	commands := s.commands()
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(CommandMetrics) uint64
+	}{
+		{"a3go_calls_total", "Calls received per command.", func(c CommandMetrics) uint64 { return c.Calls }},
+		{"a3go_errors_total", "Calls that returned an error per command.", func(c CommandMetrics) uint64 { return c.Errors }},
+		{"a3go_panics_total", "Calls that panicked per command.", func(c CommandMetrics) uint64 { return c.Panics }},
+		{"a3go_timeouts_total", "Calls that exceeded their deadline per command.", func(c CommandMetrics) uint64 { return c.Timeouts }},
+		{"a3go_truncations_total", "Responses truncated to fit the output buffer per command.", func(c CommandMetrics) uint64 { return c.Truncations }},
+		{"a3go_cache_hits_total", "Calls answered from the response cache per command.", func(c CommandMetrics) uint64 { return c.CacheHits }},
+		{"a3go_coalesced_total", "Calls that shared the response of an identical running call per command.", func(c CommandMetrics) uint64 { return c.Coalesced }},
+		{"a3go_bytes_in_total", "Bytes received from Arma per command.", func(c CommandMetrics) uint64 { return c.BytesIn }},
+		{"a3go_bytes_out_total", "Bytes returned to Arma per command.", func(c CommandMetrics) uint64 { return c.BytesOut }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+		for _, command := range commands {
+			fmt.Fprintf(&b, "%s{command=\"%s\"} %d\n", counter.name, escapeLabel(command), counter.value(s.Commands[command]))
+		}
+	}
+
+	b.WriteString("# HELP a3go_call_duration_seconds Duration of command handlers.\n# TYPE a3go_call_duration_seconds histogram\n")
+	for _, command := range commands {
+		c := s.Commands[command]
+		writeHistogram(&b, command, "sync", c.SyncLatency)
+		writeHistogram(&b, command, "async", c.AsyncLatency)
+	}
+
+	fmt.Fprintf(&b, "# HELP a3go_callbacks_sent_total Callbacks delivered to Arma.\n# TYPE a3go_callbacks_sent_total counter\na3go_callbacks_sent_total %d\n", s.CallbacksSent)
+	fmt.Fprintf(&b, "# HELP a3go_callbacks_dropped_total Callbacks that could not be delivered to Arma.\n# TYPE a3go_callbacks_dropped_total counter\na3go_callbacks_dropped_total %d\n", s.CallbacksDropped)
+	fmt.Fprintf(&b, "# HELP a3go_callback_queue Last value returned by Arma's callback function.\n# TYPE a3go_callback_queue gauge\na3go_callback_queue %d\n", s.CallbackQueue)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHistogram(b *strings.Builder, command string, mode string, h HistogramSnapshot) {
+	if h.Count == 0 {
+		return
+	}
+	labels := fmt.Sprintf(`command="%s",mode="%s"`, escapeLabel(command), mode)
+	for i, bound := range h.Buckets {
+		fmt.Fprintf(b, "a3go_call_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, bound, h.Counts[i])
+	}
+	fmt.Fprintf(b, "a3go_call_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.Count)
+	fmt.Fprintf(b, "a3go_call_duration_seconds_sum{%s} %g\n", labels, h.Sum)
+	fmt.Fprintf(b, "a3go_call_duration_seconds_count{%s} %d\n", labels, h.Count)
+}
+
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+// ServeMetrics serves the metrics in the Prometheus text exposition format at /metrics on addr, which must be a loopback address such as "127.0.0.1:9100". The returned server can be stopped with Shutdown
+func (e *Extension) ServeMetrics(addr string) (*http.Server, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics address %s: %s", addr, err.Error())
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("metrics address %s is not a loopback address", addr)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %s", addr, err.Error())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := e.Metrics().WritePrometheus(w); err != nil {
+			e.Logger().Warn("error writing metrics", "error", err.Error())
+		}
+	})
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger().Error("metrics server stopped", "error", err.Error())
+		}
+	}()
+	return server, nil
+}
+
+// RegisterStatsCommand registers a synchronous command that returns the metrics to Arma as
+// [[command, [["calls", n], ["errors", n], ...]], ...], followed by a ["_callbacks", [["sent", n], ["dropped", n], ["queue", n]]] entry. Each inner array can be passed to createHashMapFromArray
+func (e *Extension) RegisterStatsCommand(command string) error {
+	return e.Register(NewRegistration(command).
+		SetDescription("Returns call counts, errors and latencies of every command.").
+		SetResponse(`[[command, [["calls", n], ["errors", n], ...]], ..., ["_callbacks", [["sent", n], ["dropped", n], ["queue", n]]]]`).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(e.Metrics().sqf())
+			return err
+		})),
+	)
+}
+
+// sqf returns the snapshot as an SQF array, see RegisterStatsCommand
+func (s MetricsSnapshot) sqf() string {
+	ms := func(d time.Duration) float64 {
+		return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+	}
+	entries := make([]interface{}, 0, len(s.Commands)+1)
+	for _, command := range s.commands() {
+		c := s.Commands[command]
+		entries = append(entries, []interface{}{
+			command,
+			[]interface{}{
+				[]interface{}{"calls", int64(c.Calls)},
+				[]interface{}{"errors", int64(c.Errors)},
+				[]interface{}{"panics", int64(c.Panics)},
+				[]interface{}{"timeouts", int64(c.Timeouts)},
+				[]interface{}{"truncations", int64(c.Truncations)},
+				[]interface{}{"cacheHits", int64(c.CacheHits)},
+				[]interface{}{"coalesced", int64(c.Coalesced)},
+				[]interface{}{"bytesIn", int64(c.BytesIn)},
+				[]interface{}{"bytesOut", int64(c.BytesOut)},
+				[]interface{}{"meanSyncMs", ms(c.SyncLatency.Mean())},
+				[]interface{}{"meanAsyncMs", ms(c.AsyncLatency.Mean())},
+			},
+		})
+	}
+	entries = append(entries, []interface{}{
+		"_callbacks",
+		[]interface{}{
+			[]interface{}{"sent", int64(s.CallbacksSent)},
+			[]interface{}{"dropped", int64(s.CallbacksDropped)},
+			[]interface{}{"queue", s.CallbackQueue},
+		},
+	})
+	return ToArmaHashMap(entries)
+}
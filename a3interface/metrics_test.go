@@ -0,0 +1,85 @@
+package a3interface
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	e := NewExtension()
+	err := e.Register(NewRegistration("metricsTest").
+		SetAliases("metricsAlias").
+		SetHandler(HandlerFunc(func(req *Request) error {
+			if len(req.Args) > 0 {
+				return errors.New("no args expected")
+			}
+			_, err := req.Writer.WriteString(`["0123456789"]`)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	e.dispatch(CallFormString, "metricsTest", nil, 10240)
+	e.dispatch(CallFormString, "metricsAlias", nil, 10240)
+	e.dispatch(CallFormString, "metricsTest|a", nil, 10240)
+	if got := e.dispatch(CallFormString, "metricsTest", nil, 6); got != `["012` {
+		t.Errorf("dispatch() = %v, want truncated response", got)
+	}
+
+	got := e.Metrics().Commands["metricsTest"]
+	want := CommandMetrics{
+		Calls:       4,
+		Errors:      1,
+		Truncations: 1,
+		BytesIn:     uint64(len("metricsTest")*2 + len("metricsAlias") + len("metricsTest|a")),
+	}
+	if got.Calls != want.Calls || got.Errors != want.Errors || got.Truncations != want.Truncations || got.BytesIn != want.BytesIn {
+		t.Errorf("Metrics() = %+v, want %+v", got, want)
+	}
+	if got.SyncLatency.Count != 4 {
+		t.Errorf("SyncLatency.Count = %v, want %v", got.SyncLatency.Count, 4)
+	}
+
+	var b strings.Builder
+	if err := e.Metrics().WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	for _, line := range []string{
+		`a3go_calls_total{command="metricsTest"} 4`,
+		`a3go_errors_total{command="metricsTest"} 1`,
+		`a3go_call_duration_seconds_count{command="metricsTest",mode="sync"} 4`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("WritePrometheus() missing %q", line)
+		}
+	}
+}
+
+func TestMetrics_mounted(t *testing.T) {
+	e := NewExtension()
+	handler := HandlerFunc(func(req *Request) error { return nil })
+	if err := e.Register(NewRegistration("query").SetHandler(handler)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	db := NewRouter()
+	if err := NewRegistration("query").SetAliases("select").SetHandler(handler).RegisterTo(db); err != nil {
+		t.Fatalf("RegisterTo() error = %v", err)
+	}
+	if err := e.Mount("db", db); err != nil {
+		t.Fatalf("Mount() error = %v", err)
+	}
+
+	e.dispatch(CallFormString, "query", nil, 10240)
+	e.dispatch(CallFormString, "db:query", nil, 10240)
+	e.dispatch(CallFormString, "db:select", nil, 10240)
+
+	commands := e.Metrics().Commands
+	if got := commands["query"].Calls; got != 1 {
+		t.Errorf("Metrics() query calls = %v, want 1", got)
+	}
+	if got := commands["db:query"].Calls; got != 2 {
+		t.Errorf("Metrics() db:query calls = %v, want 2", got)
+	}
+}
@@ -0,0 +1,160 @@
+package a3interface
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a Handler to run code before and after it, in the same way as net/http middleware. It can inspect or replace the Request, its context and Writer, and the error returned by the next Handler
+type Middleware func(next Handler) Handler
+
+// chainMiddleware wraps handler so that the first middleware is the outermost
+func chainMiddleware(handler Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// joinMiddleware returns a new slice with the middleware of outer followed by inner
+func joinMiddleware(outer []Middleware, inner []Middleware) []Middleware {
+	joined := make([]Middleware, 0, len(outer)+len(inner))
+	joined = append(joined, outer...)
+	return append(joined, inner...)
+}
+
+// RecoverMiddleware returns a Middleware that turns a panic in the next Handler into an error. The dispatcher already recovers panics around the whole chain, this allows middleware further out to see the error
+func RecoverMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = panicError(req, r)
+				}
+			}()
+			return next.ServeRV(req)
+		})
+	}
+}
+
+// TimingMiddleware returns a Middleware that calls report with the time taken by the next Handler
+func TimingMiddleware(report func(req *Request, duration time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) error {
+			start := time.Now()
+			err := next.ServeRV(req)
+			report(req, time.Since(start), err)
+			return err
+		})
+	}
+}
+
+// LoggingMiddleware returns a Middleware that logs every call, its duration, the size of its response and any error at info level to logger, with the attributes of Request.Logger. If logger is nil, the logger of the request's Extension is used
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return TimingMiddleware(func(req *Request, duration time.Duration, err error) {
+		callLogger := req.Logger()
+		if logger != nil {
+			callLogger = logger.With(req.logAttrs()...)
+		}
+		if err != nil {
+			callLogger.Info("call failed",
+				slog.Int("args", len(req.Args)),
+				slog.Duration("duration", duration),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		callLogger.Info("call finished",
+			slog.Int("args", len(req.Args)),
+			slog.Duration("duration", duration),
+			slog.Int("bytes", req.Writer.Len()),
+		)
+	})
+}
+
+// RateLimitMiddleware returns a Middleware that allows calls at an average of perSecond calls per second with bursts of up to burst calls. Calls over the limit return an error without calling the next Handler
+func RateLimitMiddleware(perSecond float64, burst int) Middleware {
+	bucket := newTokenBucket(perSecond, burst)
+	return func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) error {
+			if !bucket.take(time.Now()) {
+				return Errorf(ErrCodeRateLimited, "rate limit exceeded for command %s", req.Command).
+					SetRetryable(true)
+			}
+			return next.ServeRV(req)
+		})
+	}
+}
+
+// tokenBucket is a token bucket rate limiter
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(perSecond float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:     perSecond,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+	}
+}
+
+// take removes a token from the bucket, returning false if none are available
+func (b *tokenBucket) take(now time.Time) bool {
+	_, ok := b.reserve(now, 0)
+	return ok
+}
+
+// reserve removes a token from the bucket if one is available within maxWait, returning the time to wait until it is. If none is, no token is removed and the time until one would be available is returned with false
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+	var wait time.Duration
+	if b.tokens < 1 {
+		if b.rate <= 0 {
+			return time.Duration(math.MaxInt64), false
+		}
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return wait, false
+	}
+	// tokens go negative while calls are queued, so later calls wait behind them
+	b.tokens--
+	return wait, true
+}
+
+// refund returns a token taken by reserve for a call that was not made after all
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// full returns true if the bucket has refilled to its capacity, meaning it can be discarded without changing any limit
+func (b *tokenBucket) full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.capacity
+}
@@ -0,0 +1,125 @@
+package a3interface
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_chainMiddleware(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(req *Request) error {
+				calls = append(calls, name+" before")
+				err := next.ServeRV(req)
+				calls = append(calls, name+" after")
+				return err
+			})
+		}
+	}
+
+	sub := NewRouter().Use(record("sub"))
+	NewRegistration("query").
+		Use(record("registration")).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			calls = append(calls, "handler")
+			return nil
+		})).
+		RegisterTo(sub)
+	router := NewRouter().Use(record("root"))
+	router.Mount("db", sub)
+
+	match := router.route("db:query")
+	handler := chainMiddleware(
+		match.registration.handler(),
+		joinMiddleware(match.middleware, match.registration.Middleware),
+	)
+	if err := handler.ServeRV(&Request{Command: "db:query", Writer: &responseBuffer{}}); err != nil {
+		t.Fatalf("ServeRV() error = %v", err)
+	}
+
+	want := []string{
+		"root before",
+		"sub before",
+		"registration before",
+		"handler",
+		"registration after",
+		"sub after",
+		"root after",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("middleware order = %v, want %v", calls, want)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	handler := RecoverMiddleware()(HandlerFunc(func(req *Request) error {
+		panic("index out of range")
+	}))
+	if err := handler.ServeRV(&Request{Command: "test"}); err == nil {
+		t.Errorf("RecoverMiddleware() error = nil, want error")
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	e := NewExtension()
+	e.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	var custom bytes.Buffer
+	tests := []struct {
+		name   string
+		logger *slog.Logger
+		err    error
+		output *bytes.Buffer
+		want   []string
+	}{
+		{name: "extension logger", output: &logs, want: []string{`msg="call finished"`, "command=logged", "args=1", "bytes=6"}},
+		{name: "failed", output: &logs, err: errors.New("boom"), want: []string{`msg="call failed"`, "command=logged", "error=boom"}},
+		{name: "logger", logger: slog.New(slog.NewTextHandler(&custom, nil)), output: &custom, want: []string{`msg="call finished"`, "command=logged"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			custom.Reset()
+			handler := LoggingMiddleware(tt.logger)(HandlerFunc(func(req *Request) error {
+				req.Writer.WriteString(`["ok"]`)
+				return tt.err
+			}))
+			handler.ServeRV(&Request{Command: "logged", Args: []string{"a"}, Writer: &responseBuffer{}, ext: e})
+			for _, want := range tt.want {
+				if !strings.Contains(tt.output.String(), want) {
+					t.Errorf("LoggingMiddleware() logged %q, want it to contain %q", tt.output.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func Test_tokenBucket_take(t *testing.T) {
+	bucket := newTokenBucket(1, 2)
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "burst 1", at: now, want: true},
+		{name: "burst 2", at: now, want: true},
+		{name: "empty", at: now, want: false},
+		{name: "refilled", at: now.Add(time.Second), want: true},
+		{name: "empty again", at: now.Add(time.Second), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucket.take(tt.at); got != tt.want {
+				t.Errorf("tokenBucket.take() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package a3interface
+
+import (
+	"sync"
+)
+
+// OrderingKey returns the key that orders the background jobs of a registration. Jobs with the same key run one at a time in the order Arma called them, while jobs with different keys run in parallel. An empty key leaves the job unordered
+type OrderingKey func(req *Request) string
+
+// OrderBySteamID orders background jobs by the SteamID of the caller
+func OrderBySteamID() OrderingKey {
+	return func(req *Request) string {
+		return req.ArmaContext.SteamID
+	}
+}
+
+// OrderByArg orders background jobs by the argument at index, with escape quotes removed. Calls without that argument are unordered
+func OrderByArg(index int) OrderingKey {
+	return func(req *Request) string {
+		if index < 0 || index >= len(req.Args) {
+			return ""
+		}
+		return req.Args[index]
+	}
+}
+
+// keyedQueue runs jobs with the same key one at a time in submission order, and jobs with different keys in parallel
+type keyedQueue struct {
+	mu     sync.Mutex
+	queues map[string][]func()
+}
+
+// submit runs job in the background once every job submitted before it under the same key has finished
+func (q *keyedQueue) submit(key string, job func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queues == nil {
+		q.queues = make(map[string][]func())
+	}
+	pending, running := q.queues[key]
+	q.queues[key] = append(pending, job)
+	if !running {
+		go q.run(key)
+	}
+}
+
+// run drains the jobs of a key, removing the key once it has no jobs left
+func (q *keyedQueue) run(key string) {
+	for {
+		q.mu.Lock()
+		pending := q.queues[key]
+		if len(pending) == 0 {
+			delete(q.queues, key)
+			q.mu.Unlock()
+			return
+		}
+		job := pending[0]
+		q.queues[key] = pending[1:]
+		q.mu.Unlock()
+		job()
+	}
+}
+
+// orderingKey returns the ordering key of a background request, or an empty string if its registration has none
+func orderingKey(registration *RVExtensionRegistration, req *Request) string {
+	if registration.OrderingKey == nil {
+		return ""
+	}
+	return registration.OrderingKey(req)
+}
@@ -0,0 +1,104 @@
+package a3interface
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_keyedQueue_submit(t *testing.T) {
+	q := &keyedQueue{}
+	var (
+		mu    sync.Mutex
+		order = map[string][]int{}
+		wg    sync.WaitGroup
+	)
+	block := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		i := i
+		key := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		q.submit(key, func() {
+			defer wg.Done()
+			if i == 0 {
+				// key b must keep running while key a is blocked
+				<-block
+			}
+			mu.Lock()
+			order[key] = append(order[key], i)
+			mu.Unlock()
+		})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		done := len(order["b"])
+		mu.Unlock()
+		if done == 10 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(block)
+	wg.Wait()
+
+	for key, indexes := range order {
+		for n := 1; n < len(indexes); n++ {
+			if indexes[n] < indexes[n-1] {
+				t.Errorf("key %s ran %v, want submission order", key, indexes)
+				break
+			}
+		}
+	}
+	if len(order["a"]) != 10 || len(order["b"]) != 10 {
+		t.Errorf("ran %d jobs for a and %d for b, want 10 each", len(order["a"]), len(order["b"]))
+	}
+	// the last job of each key is done before its key is removed
+	deadline = time.Now().Add(time.Second)
+	q.mu.Lock()
+	for len(q.queues) != 0 && time.Now().Before(deadline) {
+		q.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+	if len(q.queues) != 0 {
+		t.Errorf("%d keys left after all jobs finished, want 0", len(q.queues))
+	}
+}
+
+func TestRVExtensionRegistration_SetOrderingKey(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		saved []string
+		wg    sync.WaitGroup
+	)
+	e := NewExtension()
+	err := e.Register(NewRegistration("orderedSave").
+		SetRunInBackground(true).
+		SetOrderingKey(OrderByArg(0)).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			defer wg.Done()
+			// earlier saves take longer, so they would finish last without ordering
+			if req.Args[1] == "1" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			mu.Lock()
+			saved = append(saved, req.Args[1])
+			mu.Unlock()
+			return nil
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	wg.Add(2)
+	e.dispatch(CallFormArgs, "orderedSave", []string{`"player1"`, "1"}, 10240)
+	e.dispatch(CallFormArgs, "orderedSave", []string{`"player1"`, "2"}, 10240)
+	wg.Wait()
+
+	if len(saved) != 2 || saved[0] != "1" || saved[1] != "2" {
+		t.Errorf("saved %v, want [1 2]", saved)
+	}
+}
@@ -0,0 +1,219 @@
+package a3interface
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitScope determines which calls share a token bucket
+type RateLimitScope int
+
+const (
+	// RateLimitPerCommand gives every command one bucket shared by all of its callers
+	RateLimitPerCommand RateLimitScope = iota
+	// RateLimitPerSteamID gives every SteamID its own bucket
+	RateLimitPerSteamID
+	// RateLimitPerRemoteExecutedOwner gives every client that remote executes the call its own bucket. Calls that were not remote executed, with an owner of "" or "0", are not limited
+	RateLimitPerRemoteExecutedOwner
+)
+
+// String returns the name of the scope as used in errors and events
+func (s RateLimitScope) String() string {
+	switch s {
+	case RateLimitPerCommand:
+		return "command"
+	case RateLimitPerSteamID:
+		return "steam_id"
+	case RateLimitPerRemoteExecutedOwner:
+		return "remote_executed_owner"
+	default:
+		return fmt.Sprintf("RateLimitScope(%d)", int(s))
+	}
+}
+
+// key returns the bucket key of a request in this scope, and false if the scope doesn't limit the request
+func (s RateLimitScope) key(req *Request) (string, bool) {
+	switch s {
+	case RateLimitPerSteamID:
+		return req.ArmaContext.SteamID, true
+	case RateLimitPerRemoteExecutedOwner:
+		return req.ArmaContext.RemoteExecutedOwner, isRemoteExecuted(req.ArmaContext)
+	default:
+		return req.Pattern, true
+	}
+}
+
+// RateLimitAction determines what happens to a call over the limit
+type RateLimitAction int
+
+const (
+	// RateLimitReject returns a RATE_LIMITED error to Arma
+	RateLimitReject RateLimitAction = iota
+	// RateLimitQueue delays the call until a token is available, for up to MaxWait. Synchronous calls block Arma while they wait, so queueing is best suited to background registrations
+	RateLimitQueue
+)
+
+// RateLimit is a token bucket policy. Calls are allowed at an average of PerSecond per second, with bursts of up to Burst calls
+type RateLimit struct {
+	Scope     RateLimitScope
+	PerSecond float64
+	Burst     int
+	Action    RateLimitAction
+	// MaxWait is the longest a call is queued for with RateLimitQueue. Calls that would wait longer are rejected
+	MaxWait time.Duration
+}
+
+// rateLimitSweepInterval is how often idle buckets are discarded
+const rateLimitSweepInterval = time.Minute
+
+// bucketKey identifies the bucket of a caller for a rate limit. registration is nil for the limits set with SetRateLimit
+type bucketKey struct {
+	registration *RVExtensionRegistration
+	index        int
+	key          string
+}
+
+// rateLimiter holds the token buckets of every rate limit
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    []RateLimit
+	buckets   map[bucketKey]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[bucketKey]*tokenBucket)}
+}
+
+// SetRateLimit sets rate limits applied to every call, in addition to those of its registration, i.e. RateLimit{Scope: RateLimitPerSteamID, PerSecond: 10, Burst: 20} limits each player across every command
+func (e *Extension) SetRateLimit(limits ...RateLimit) {
+	e.rateLimiter.mu.Lock()
+	defer e.rateLimiter.mu.Unlock()
+	e.rateLimiter.limits = limits
+	// buckets of the previous limits no longer apply
+	for key := range e.rateLimiter.buckets {
+		if key.registration == nil {
+			delete(e.rateLimiter.buckets, key)
+		}
+	}
+}
+
+// bucket returns the token bucket of a caller, creating it if needed
+func (l *rateLimiter) bucket(key bucketKey, limit RateLimit, now time.Time) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > rateLimitSweepInterval {
+		l.lastSweep = now
+		for k, b := range l.buckets {
+			if b.full(now) {
+				delete(l.buckets, k)
+			}
+		}
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newTokenBucket(limit.PerSecond, limit.Burst)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// reservation is a token taken from a bucket for a call
+type reservation struct {
+	bucket *tokenBucket
+	limit  RateLimit
+	wait   time.Duration
+}
+
+// admit takes a token for a call from every bucket that applies to it. It returns how long the call must be queued for, or an ErrCodeRateLimited error if it is rejected. A rejected call takes no tokens, so it doesn't use up the budget of other commands sharing a bucket
+func (l *rateLimiter) admit(req *Request, registration *RVExtensionRegistration) (time.Duration, error) {
+	l.mu.Lock()
+	global := l.limits
+	l.mu.Unlock()
+
+	now := time.Now()
+	var reservations []reservation
+	check := func(owner *RVExtensionRegistration, index int, limit RateLimit) error {
+		maxWait := time.Duration(0)
+		if limit.Action == RateLimitQueue {
+			maxWait = limit.MaxWait
+		}
+		scopeKey, limited := limit.Scope.key(req)
+		if !limited {
+			return nil
+		}
+		key := bucketKey{registration: owner, index: index, key: scopeKey}
+		bucket := l.bucket(key, limit, now)
+		d, ok := bucket.reserve(now, maxWait)
+		if !ok {
+			for _, r := range reservations {
+				r.bucket.refund()
+			}
+			req.Extension().publishThrottled(req, registration, limit, "rejected")
+			retryAfter := math.Ceil(d.Seconds()*1000) / 1000
+			return Errorf(ErrCodeRateLimited, "rate limit exceeded for command %s", req.Command).
+				SetDetails([]interface{}{
+					[]interface{}{"scope", limit.Scope.String()},
+					[]interface{}{"retryAfter", retryAfter},
+				}).
+				SetRetryable(true)
+		}
+		reservations = append(reservations, reservation{bucket: bucket, limit: limit, wait: d})
+		return nil
+	}
+
+	for index, limit := range global {
+		if err := check(nil, index, limit); err != nil {
+			return 0, err
+		}
+	}
+	for index, limit := range registration.RateLimits {
+		if err := check(registration, index, limit); err != nil {
+			return 0, err
+		}
+	}
+
+	var wait time.Duration
+	for _, r := range reservations {
+		if r.wait > 0 {
+			req.Extension().publishThrottled(req, registration, r.limit, fmt.Sprintf("queued for %s", r.wait))
+		}
+		if r.wait > wait {
+			wait = r.wait
+		}
+	}
+	return wait, nil
+}
+
+// publishThrottled logs and publishes EventThrottled for a call over a rate limit
+func (e *Extension) publishThrottled(req *Request, registration *RVExtensionRegistration, limit RateLimit, outcome string) {
+	background := registration.RunInBackground
+	e.Logger().Warn("call throttled",
+		append(req.logAttrs(),
+			slog.String("scope", limit.Scope.String()),
+			slog.String("outcome", outcome),
+		)...,
+	)
+	e.events.publish(Event{
+		Type:    EventThrottled,
+		Call:    req.callInfo(background),
+		Message: fmt.Sprintf("command %s over the %s rate limit: %s", req.Command, limit.Scope, outcome),
+	})
+}
+
+// delayed returns a Handler that waits before calling handler, or returns the context's error if it is done first
+func delayed(handler Handler, wait time.Duration) Handler {
+	return HandlerFunc(func(req *Request) error {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return handler.ServeRV(req)
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	})
+}
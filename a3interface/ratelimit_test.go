@@ -0,0 +1,147 @@
+package a3interface
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRVExtensionRegistration_SetRateLimit(t *testing.T) {
+	e := NewExtension()
+	subscription := e.Subscribe(16, EventThrottled)
+	defer subscription.Unsubscribe()
+
+	err := e.Register(NewRegistration("rateLimited").
+		SetRateLimit(RateLimit{Scope: RateLimitPerSteamID, PerSecond: 0.001, Burst: 2}).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, err := req.Writer.WriteString(`["ok"]`)
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		steamID string
+		want    string
+	}{
+		{name: "burst 1", steamID: "1", want: `["ok"]`},
+		{name: "burst 2", steamID: "1", want: `["ok"]`},
+		{name: "rejected", steamID: "1", want: `["error", "RATE_LIMITED", "rate limit exceeded for command rateLimited", [["scope", "steam_id"], ["retryAfter", 1000]], true]`},
+		{name: "other player", steamID: "2", want: `["ok"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.SetContext(ArmaExtensionContext{SteamID: tt.steamID})
+			if got := e.dispatch(CallFormString, "rateLimited", nil, 10240); got != tt.want {
+				t.Errorf("dispatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	select {
+	case event := <-subscription.C:
+		if event.Call == nil || event.Call.ArmaContext.SteamID != "1" || !strings.HasSuffix(event.Message, "rejected") {
+			t.Errorf("event = %+v, want rejected call of SteamID 1", event)
+		}
+	default:
+		t.Error("no throttled event published")
+	}
+}
+
+func TestRateLimitQueue(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		times []time.Time
+		wg    sync.WaitGroup
+	)
+	e := NewExtension()
+	err := e.Register(NewRegistration("rateQueued").
+		SetRunInBackground(true).
+		SetRateLimit(RateLimit{Scope: RateLimitPerCommand, PerSecond: 20, Burst: 1, Action: RateLimitQueue, MaxWait: time.Second}).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			defer wg.Done()
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+			return nil
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	start := time.Now()
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		if got := e.dispatch(CallFormString, "rateQueued", nil, 10240); got != `["Command rateQueued called"]` {
+			t.Errorf("dispatch() = %v, want default response", got)
+		}
+	}
+	wg.Wait()
+
+	// the third call waits for two tokens at 20 per second
+	if elapsed := times[len(times)-1].Sub(start); elapsed < 90*time.Millisecond {
+		t.Errorf("last call ran after %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestRateLimit_rejectedTakesNoTokens(t *testing.T) {
+	e := NewExtension()
+	e.SetContext(ArmaExtensionContext{SteamID: "1"})
+	e.SetRateLimit(RateLimit{Scope: RateLimitPerSteamID, PerSecond: 0.001, Burst: 3})
+	ok := HandlerFunc(func(req *Request) error {
+		_, err := req.Writer.WriteString(`["ok"]`)
+		return err
+	})
+	if err := e.Register(NewRegistration("a").SetRateLimit(RateLimit{PerSecond: 0.001, Burst: 1}).SetHandler(ok)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := e.Register(NewRegistration("b").SetHandler(ok)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// the first call of "a" takes a token from both buckets, the others are rejected by the limit of "a"
+	for i := 0; i < 5; i++ {
+		e.dispatch(CallFormString, "a", nil, 10240)
+	}
+	for i := 0; i < 2; i++ {
+		if got := e.dispatch(CallFormString, "b", nil, 10240); got != `["ok"]` {
+			t.Errorf("dispatch() call %d of b = %v, want %v", i+1, got, `["ok"]`)
+		}
+	}
+}
+
+func TestRateLimitPerRemoteExecutedOwner(t *testing.T) {
+	e := NewExtension()
+	e.SetRateLimit(RateLimit{Scope: RateLimitPerRemoteExecutedOwner, PerSecond: 0.001, Burst: 1})
+	err := e.Register(NewRegistration("remoteLimited").SetHandler(HandlerFunc(func(req *Request) error {
+		_, err := req.Writer.WriteString(`["ok"]`)
+		return err
+	})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		owner string
+		want  bool
+	}{
+		{name: "server", owner: "0", want: true},
+		{name: "server again", owner: "0", want: true},
+		{name: "no owner", owner: "", want: true},
+		{name: "client", owner: "3", want: true},
+		{name: "client again", owner: "3", want: false},
+		{name: "other client", owner: "4", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.SetContext(ArmaExtensionContext{RemoteExecutedOwner: tt.owner})
+			if got := e.dispatch(CallFormString, "remoteLimited", nil, 10240) == `["ok"]`; got != tt.want {
+				t.Errorf("dispatch() allowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package a3interface
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordType is the kind of traffic a RecordEntry holds
+type RecordType string
+
+const (
+	// RecordContext is a context sent by Arma that differs from the previous one
+	RecordContext RecordType = "context"
+	// RecordCall is a call from Arma and the response it received
+	RecordCall RecordType = "call"
+	// RecordCallback is a callback sent to Arma
+	RecordCallback RecordType = "callback"
+)
+
+// DefaultReplayCallbackWait is how long Replay waits for the recorded callbacks after the last call if ReplayOptions.CallbackWait is 0
+const DefaultReplayCallbackWait = time.Second
+
+// RecordEntry is a line of a recording
+type RecordEntry struct {
+	Time time.Time  `json:"t"`
+	Type RecordType `json:"type"`
+
+	// Context is set for RecordContext
+	Context *ArmaExtensionContext `json:"context,omitempty"`
+
+	// Form, Input, Args, OutputSize and Response are set for RecordCall. Input and Args are as Arma sent them
+	Form       CallForm `json:"form,omitempty"`
+	Input      string   `json:"in,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	OutputSize int      `json:"size,omitempty"`
+	Response   string   `json:"out,omitempty"`
+
+	// Name, Function, Data and Result are set for RecordCallback. Result is the value Arma's callback function returned, or 0 if it was not set
+	Name     string `json:"name,omitempty"`
+	Function string `json:"fn,omitempty"`
+	Data     string `json:"data,omitempty"`
+	Result   int    `json:"result,omitempty"`
+}
+
+// Recorder writes the traffic of an Extension as JSON lines, one RecordEntry per line. Set it with SetRecorder
+type Recorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewRecorder returns a Recorder that writes to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// NewFileRecorder returns a Recorder that appends to the file at path
+func NewFileRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening recording: %s", err.Error())
+	}
+	recorder := NewRecorder(file)
+	recorder.closer = file
+	return recorder, nil
+}
+
+// Record writes an entry
+func (r *Recorder) Record(entry RecordEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.encoder.Encode(entry)
+}
+
+// Close closes the underlying file if the recorder was created with NewFileRecorder
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// SetRecorder records every context, call, response and callback of the extension to recorder, starting with the current context. Pass nil to stop recording
+func (e *Extension) SetRecorder(recorder *Recorder) {
+	e.recorder.Store(recorder)
+	if recorder != nil {
+		ctx := e.Context()
+		e.record(RecordEntry{Type: RecordContext, Context: &ctx})
+	}
+}
+
+// record writes an entry if recording is enabled, logging errors
+func (e *Extension) record(entry RecordEntry) {
+	recorder := e.recorder.Load()
+	if recorder == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if err := recorder.Record(entry); err != nil {
+		e.Logger().Warn("error recording", "type", string(entry.Type), "error", err.Error())
+	}
+}
+
+// ReadRecording reads the entries written by a Recorder
+func ReadRecording(r io.Reader) ([]RecordEntry, error) {
+	var entries []RecordEntry
+	scanner := bufio.NewScanner(r)
+	// responses and callbacks can be larger than the default token size
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry RecordEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("error parsing recording line %d: %s", number, err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading recording: %s", err.Error())
+	}
+	return entries, nil
+}
+
+// ReplayOptions control how Replay feeds a recording through an Extension
+type ReplayOptions struct {
+	// Speed scales the time between recorded calls, 1 replays at the original timing and 2 twice as fast. With 0, calls are replayed without waiting
+	Speed float64
+	// CallbackWait is how long to wait for the recorded callbacks after the last call, DefaultReplayCallbackWait if 0
+	CallbackWait time.Duration
+	// Normalize is applied to recorded and replayed responses and callback data before they are compared, i.e. to remove the job IDs of pending responses
+	Normalize func(s string) string
+}
+
+// ReplayDiff is a difference between a recording and its replay
+type ReplayDiff struct {
+	// Index is the index of the recorded entry, or -1 for a callback that was not recorded
+	Index int
+	Type  RecordType
+	// Name is the command of a call, or the function of a callback
+	Name string
+	Want string
+	Got  string
+}
+
+// String describes the difference with the recorded value prefixed by - and the replayed value by +
+func (d ReplayDiff) String() string {
+	switch {
+	case d.Index < 0:
+		return fmt.Sprintf("unexpected %s %s\n+ %s", d.Type, d.Name, d.Got)
+	case d.Type == RecordCallback:
+		return fmt.Sprintf("entry %d: missing %s %s\n- %s", d.Index, d.Type, d.Name, d.Want)
+	default:
+		return fmt.Sprintf("entry %d: %s %s\n- %s\n+ %s", d.Index, d.Type, d.Name, d.Want, d.Got)
+	}
+}
+
+// ReplayReport is the result of a replay
+type ReplayReport struct {
+	Calls     int
+	Callbacks int
+	Diffs     []ReplayDiff
+}
+
+// Write writes every difference followed by a summary
+func (r ReplayReport) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, diff := range r.Diffs {
+		b.WriteString(diff.String())
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "replayed %d calls and %d callbacks, %d differences\n", r.Calls, r.Callbacks, len(r.Diffs))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Replay feeds the contexts and calls of a recording through the extension and compares the responses and callbacks with the recorded ones. Callbacks are matched regardless of their order. The extension's callback function is replaced while replaying, so the extension should not be serving Arma
+func (e *Extension) Replay(entries []RecordEntry, options ReplayOptions) ReplayReport {
+	normalize := options.Normalize
+	if normalize == nil {
+		normalize = func(s string) string { return s }
+	}
+	callbackWait := options.CallbackWait
+	if callbackWait == 0 {
+		callbackWait = DefaultReplayCallbackWait
+	}
+
+	var mu sync.Mutex
+	var received []RecordEntry
+	changed := make(chan struct{}, 1)
+	previous := e.callbackFunc()
+	e.SetCallback(func(name string, function string, data string) int {
+		mu.Lock()
+		received = append(received, RecordEntry{Type: RecordCallback, Name: name, Function: function, Data: data})
+		mu.Unlock()
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+		return 0
+	})
+	defer e.SetCallback(previous)
+
+	var report ReplayReport
+	var expected []int
+	var last time.Time
+	for index, entry := range entries {
+		if entry.Type == RecordCallback {
+			expected = append(expected, index)
+			continue
+		}
+		if options.Speed > 0 && !last.IsZero() && entry.Time.After(last) {
+			time.Sleep(time.Duration(float64(entry.Time.Sub(last)) / options.Speed))
+		}
+		last = entry.Time
+
+		switch entry.Type {
+		case RecordContext:
+			if entry.Context != nil {
+				e.SetContext(*entry.Context)
+			}
+		case RecordCall:
+			report.Calls++
+			var got string
+			if entry.Form == CallFormArgs {
+				got = e.CallArgs(entry.Input, entry.Args, entry.OutputSize)
+			} else {
+				got = e.Call(entry.Input, entry.OutputSize)
+			}
+			if normalize(got) != normalize(entry.Response) {
+				report.Diffs = append(report.Diffs, ReplayDiff{
+					Index: index,
+					Type:  RecordCall,
+					Name:  entry.Input,
+					Want:  entry.Response,
+					Got:   got,
+				})
+			}
+		}
+	}
+
+	// callbacks arrive from background handlers, possibly after the last call
+	timer := time.NewTimer(callbackWait)
+	defer timer.Stop()
+	for waiting := true; waiting; {
+		mu.Lock()
+		done := len(received) >= len(expected)
+		mu.Unlock()
+		if done {
+			break
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			waiting = false
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	report.Callbacks = len(received)
+	matched := make([]bool, len(received))
+	for _, index := range expected {
+		want := entries[index]
+		found := false
+		for i, got := range received {
+			if !matched[i] && got.Name == want.Name && got.Function == want.Function && normalize(got.Data) == normalize(want.Data) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			report.Diffs = append(report.Diffs, ReplayDiff{
+				Index: index,
+				Type:  RecordCallback,
+				Name:  want.Function,
+				Want:  want.Data,
+			})
+		}
+	}
+	for i, got := range received {
+		if !matched[i] {
+			report.Diffs = append(report.Diffs, ReplayDiff{
+				Index: -1,
+				Type:  RecordCallback,
+				Name:  got.Function,
+				Got:   got.Data,
+			})
+		}
+	}
+	return report
+}
+
+// ReplayFile replays the recording at path, see Extension.Replay
+func (e *Extension) ReplayFile(path string, options ReplayOptions) (ReplayReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ReplayReport{}, fmt.Errorf("error opening recording: %s", err.Error())
+	}
+	defer file.Close()
+	entries, err := ReadRecording(file)
+	if err != nil {
+		return ReplayReport{}, err
+	}
+	return e.Replay(entries, options), nil
+}
@@ -0,0 +1,108 @@
+package a3interface
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that callbacks can write to while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestExtension_Replay(t *testing.T) {
+	newExtension := func(greeting string) *Extension {
+		e := NewExtension()
+		e.SetCallback(func(name string, function string, data string) int { return 0 })
+		e.Register(NewRegistration("greet").
+			SetHandler(HandlerFunc(func(req *Request) error {
+				_, err := req.Writer.WriteString(ToArmaHashMap([]interface{}{greeting, req.ArmaContext.SteamID}))
+				return err
+			})))
+		e.Register(NewRegistration("greetLater").
+			SetRunInBackground(true).
+			SetHandler(HandlerFunc(func(req *Request) error {
+				return req.Extension().WriteArmaCallback("replay", "greeted", greeting+" "+req.Args[0])
+			})))
+		return e
+	}
+
+	recording := &lockedBuffer{}
+	recorded := newExtension("hello")
+	recorded.SetRecorder(NewRecorder(recording))
+	recorded.Call("greet", 10240)
+	recorded.SetContext(ArmaExtensionContext{SteamID: "2"})
+	recorded.SetContext(ArmaExtensionContext{SteamID: "2"})
+	recorded.CallArgs("greet", nil, 10240)
+	recorded.Call("greetLater|bob", 10240)
+	for deadline := time.Now().Add(time.Second); !strings.Contains(recording.String(), `"callback"`); {
+		if time.Now().After(deadline) {
+			t.Fatalf("recording = %v, want a callback", recording.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	entries, err := ReadRecording(strings.NewReader(recording.String()))
+	if err != nil {
+		t.Fatalf("ReadRecording() error = %v", err)
+	}
+	var types []string
+	for _, entry := range entries {
+		types = append(types, string(entry.Type))
+	}
+	if got, want := strings.Join(types, " "), "context call context call call callback"; got != want {
+		t.Fatalf("ReadRecording() types = %v, want %v", got, want)
+	}
+	if entries[3].Form != CallFormArgs || entries[3].Response != `["hello", "2"]` {
+		t.Errorf("ReadRecording() call = %+v, want RVExtensionArgs with the recorded response", entries[3])
+	}
+
+	tests := []struct {
+		name     string
+		greeting string
+		want     []string
+	}{
+		{name: "same", greeting: "hello"},
+		{
+			name:     "changed",
+			greeting: "hi",
+			want: []string{
+				"entry 1: call greet\n- [\"hello\", \"123456789\"]\n+ [\"hi\", \"123456789\"]",
+				"entry 3: call greet\n- [\"hello\", \"2\"]\n+ [\"hi\", \"2\"]",
+				"entry 5: missing callback greeted\n- [\"hello bob\"]",
+				"unexpected callback greeted\n+ [\"hi bob\"]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := newExtension(tt.greeting).Replay(entries, ReplayOptions{Speed: 10, CallbackWait: 100 * time.Millisecond})
+			if report.Calls != 3 || report.Callbacks != 1 {
+				t.Errorf("Replay() calls %v callbacks %v, want 3 and 1", report.Calls, report.Callbacks)
+			}
+			var got []string
+			for _, diff := range report.Diffs {
+				got = append(got, diff.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Replay() diffs =\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
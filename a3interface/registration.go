@@ -1,10 +1,12 @@
 package a3interface
 
-import "fmt"
+import "time"
 
 type RVExtensionRegistration struct {
-	// Command When this command is sent as the first element of a pipe-delimited string in RVExtension or as the command element in RVExtensionArgs, this registration will be referenced. i.e. "command|data" or ["command", ["data"]]. This is case sensitive & will call Function or ArgsFunction based on the call type used.
+	// Command When this command is sent as the first element of a pipe-delimited string in RVExtension or as the command element in RVExtensionArgs, this registration will be referenced. i.e. "command|data" or ["command", ["data"]]. This is case sensitive unless the router is set to be case insensitive & will call Function or ArgsFunction based on the call type used. It may be a pattern, see Router
 	Command string
+	// Aliases are alternative commands that will also reference this registration
+	Aliases []string
 	// DefaultResponse will be returned to Arma if Async is true. If Async is false, this value is ignored
 	DefaultResponse string
 	// RunInBackground determines whether or not the library will respond instantly to Arma with DefaultResponse or wait for a return from the function
@@ -19,6 +21,57 @@ type RVExtensionRegistration struct {
 		ctx ArmaExtensionContext,
 		command string,
 		args []string) (string, error)
+
+	// Handler is called for both call forms with a *Request describing the call. If set, Function and ArgsFunction are ignored
+	Handler Handler
+
+	// ResponseEnvelope wraps successful responses as ["ok", response]. Errors are always sent as ["error", code, message, details, retryable]
+	ResponseEnvelope bool
+
+	// ArgSchema describes the arguments this command accepts. If set, calls with arguments that don't match are rejected before the handler is called
+	ArgSchema *ArgSchema
+
+	// OrderingKey orders the background jobs of this registration, see SetOrderingKey. If nil, background jobs run in any order
+	OrderingKey OrderingKey
+
+	// TimeBudget is the time Arma waits for a synchronous handler. A handler that takes longer keeps running in the background and its result is delivered by callback, see SetTimeBudget. 0 means no budget
+	TimeBudget time.Duration
+
+	// AccessPolicy restricts who can call this command, see SetAccessPolicy
+	AccessPolicy *AccessPolicy
+
+	// RateLimits are the rate limits of this command, see SetRateLimit
+	RateLimits []RateLimit
+
+	// Coalesce makes identical synchronous calls that arrive while one is running share its response, see SetCoalesce
+	Coalesce bool
+
+	// CacheTTL is the time the successful responses of synchronous calls are cached for, see SetCache. 0 disables the cache
+	CacheTTL time.Duration
+
+	// CacheSize is the maximum number of responses cached for this registration, the least recently used are evicted first
+	CacheSize int
+
+	// CachePerCaller keeps the cached and coalesced responses of every caller apart, see SetCachePerCaller
+	CachePerCaller bool
+
+	// Middleware wraps the handler of this registration, inside any middleware of the routers it was matched through
+	Middleware []Middleware
+
+	// Description says what the command does, for the manifest and generated documentation
+	Description string
+
+	// Example is an SQF call of the command for the manifest and generated documentation, i.e. "MyExtension" callExtension ["save", [[1, 2]]]
+	Example string
+
+	// Response describes the response sent to Arma on success, i.e. [saved, [name, ...]], for the manifest and generated documentation
+	Response string
+
+	// Callbacks are the callbacks the command sends with WriteArmaCallback, for the manifest and generated code. The "pending" callback of a TimeBudget is added automatically
+	Callbacks []CallbackSpec
+
+	// fallback is true for registrations created by a router for its fallback handler
+	fallback bool
 }
 
 func NewRegistration(command string) *RVExtensionRegistration {
@@ -33,6 +86,12 @@ func (r *RVExtensionRegistration) SetDefaultResponse(response string) *RVExtensi
 	return r
 }
 
+// SetAliases sets alternative commands that will also reference this registration
+func (r *RVExtensionRegistration) SetAliases(aliases ...string) *RVExtensionRegistration {
+	r.Aliases = aliases
+	return r
+}
+
 // SetRunInBackground determines whether or not the library will respond instantly to Arma with DefaultResponse and run the function in a goroutine (true), or wait for a return from the function (false)
 func (r *RVExtensionRegistration) SetRunInBackground(runInBackground bool) *RVExtensionRegistration {
 	r.RunInBackground = runInBackground
@@ -55,14 +114,114 @@ func (r *RVExtensionRegistration) SetArgsFunction(
 	return r
 }
 
-// Register adds this registration to the list of registrations that will be used to determine how to handle calls to the extension
-func (r *RVExtensionRegistration) Register() error {
-	for _, reg := range config.registrations {
-		if reg.Command == r.Command {
-			return fmt.Errorf("command %s already registered", r.Command)
-		}
+// SetHandler sets the Handler that will be called for both the "extension" callExtension "command|data" and "extension" callExtension ["command", ["data"]] formats
+func (r *RVExtensionRegistration) SetHandler(handler Handler) *RVExtensionRegistration {
+	r.Handler = handler
+	return r
+}
+
+// SetResponseEnvelope determines whether successful responses of this registration are wrapped as ["ok", response]
+func (r *RVExtensionRegistration) SetResponseEnvelope(responseEnvelope bool) *RVExtensionRegistration {
+	r.ResponseEnvelope = responseEnvelope
+	return r
+}
+
+// SetArgSchema sets the schema the arguments of every call are validated against before the handler is called
+func (r *RVExtensionRegistration) SetArgSchema(schema *ArgSchema) *RVExtensionRegistration {
+	r.ArgSchema = schema
+	return r
+}
+
+// SetOrderingKey makes background jobs with the same key run one at a time in the order Arma called them, i.e. SetOrderingKey(OrderBySteamID()) so that two saves for the same player can't race. Keys are shared by every registration, so jobs of different commands can be ordered together. Only applies if RunInBackground is true
+func (r *RVExtensionRegistration) SetOrderingKey(key OrderingKey) *RVExtensionRegistration {
+	r.OrderingKey = key
+	return r
+}
+
+// SetTimeBudget sets the time Arma waits for a synchronous handler. If the handler exceeds it, Arma receives ["pending", jobID] and the handler finishes in the background. Its response, or error, is then sent by callback to the PendingCallbackFunction function as [jobID, response]. The request's context has a deadline at the end of the budget, but is not cancelled when it passes so that the handler can finish its work
+func (r *RVExtensionRegistration) SetTimeBudget(budget time.Duration) *RVExtensionRegistration {
+	r.TimeBudget = budget
+	return r
+}
+
+// SetAccessPolicy restricts who can call this command. Calls that are denied return an ACCESS_DENIED error before the handler or any other check is run, and publish EventAccessDenied. Policies loaded with LoadAccessPolicies apply as well
+func (r *RVExtensionRegistration) SetAccessPolicy(policy *AccessPolicy) *RVExtensionRegistration {
+	r.AccessPolicy = policy
+	return r
+}
+
+// SetRateLimit sets token bucket rate limits for this command, checked before the handler is run. Calls over a limit are rejected with a RATE_LIMITED error or queued, depending on the limit's Action, and publish EventThrottled. Limits set with Extension.SetRateLimit apply as well
+func (r *RVExtensionRegistration) SetRateLimit(limits ...RateLimit) *RVExtensionRegistration {
+	r.RateLimits = limits
+	return r
+}
+
+// SetCoalesce determines whether identical synchronous calls, with the same command, call form and arguments, and caller with SetCachePerCaller, that arrive while one is running wait for it and share its response instead of running the handler again
+func (r *RVExtensionRegistration) SetCoalesce(coalesce bool) *RVExtensionRegistration {
+	r.Coalesce = coalesce
+	return r
+}
+
+// SetCache caches the successful responses of synchronous calls for ttl, keyed by command, call form and arguments, and caller with SetCachePerCaller. At most maxEntries responses are kept, or DefaultCacheSize if maxEntries is 0 or less. Errors and pending responses are never cached. Cached responses skip the handler and its middleware, use InvalidateCache to remove them early
+func (r *RVExtensionRegistration) SetCache(ttl time.Duration, maxEntries int) *RVExtensionRegistration {
+	r.CacheTTL = ttl
+	r.CacheSize = maxEntries
+	return r
+}
+
+// SetCachePerCaller determines whether cached and coalesced responses are only shared by calls of the same SteamID and remote executed owner. By default they are shared by every caller, so handlers that answer per caller must set it
+func (r *RVExtensionRegistration) SetCachePerCaller(perCaller bool) *RVExtensionRegistration {
+	r.CachePerCaller = perCaller
+	return r
+}
+
+// SetDescription sets what the command does, for the manifest and generated documentation
+func (r *RVExtensionRegistration) SetDescription(description string) *RVExtensionRegistration {
+	r.Description = description
+	return r
+}
+
+// SetExample sets an SQF call of the command, for the manifest and generated documentation
+func (r *RVExtensionRegistration) SetExample(example string) *RVExtensionRegistration {
+	r.Example = example
+	return r
+}
+
+// SetResponse describes the response sent to Arma on success, for the manifest and generated documentation
+func (r *RVExtensionRegistration) SetResponse(response string) *RVExtensionRegistration {
+	r.Response = response
+	return r
+}
+
+// SetCallbacks declares the callbacks the command sends with WriteArmaCallback, for the manifest and generated code
+func (r *RVExtensionRegistration) SetCallbacks(callbacks ...CallbackSpec) *RVExtensionRegistration {
+	r.Callbacks = callbacks
+	return r
+}
+
+// Use adds middleware that wraps the handler of this registration only
+func (r *RVExtensionRegistration) Use(middleware ...Middleware) *RVExtensionRegistration {
+	r.Middleware = append(r.Middleware, middleware...)
+	return r
+}
+
+// handler returns the Handler for this registration, adapting Function and ArgsFunction if no Handler was set
+func (r *RVExtensionRegistration) handler() Handler {
+	if r.Handler != nil {
+		return r.Handler
+	}
+	return legacyHandler{
+		function:     r.Function,
+		argsFunction: r.ArgsFunction,
 	}
+}
+
+// Register adds this registration to the list of registrations that will be used to determine how to handle calls to the extension. It registers to the default Extension, use Extension.Register for others
+func (r *RVExtensionRegistration) Register() error {
+	return defaultExtension.Register(r)
+}
 
-	config.registrations = append(config.registrations, *r)
-	return nil
+// RegisterTo adds this registration to a router, which can be mounted under a prefix using Mount
+func (r *RVExtensionRegistration) RegisterTo(router *Router) error {
+	return router.Register(r)
 }
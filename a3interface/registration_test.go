@@ -191,7 +191,7 @@ func TestRVExtensionRegistration_SetArgsFunction(t *testing.T) {
 	}
 }
 
-func TestRVExtensionRegistration_Register(t *testing.T) {
+func TestExtension_Register(t *testing.T) {
 	tests := []struct {
 		name    string
 		r       *RVExtensionRegistration
@@ -216,16 +216,17 @@ func TestRVExtensionRegistration_Register(t *testing.T) {
 	}
 
 	// create a new registration as 'test' for checking duplicates
-	err := NewRegistration("test").Register()
+	e := NewExtension()
+	err := e.Register(NewRegistration("test"))
 	if err != nil {
-		t.Errorf("RVExtensionRegistration.Register() error = %v", err)
+		t.Errorf("Extension.Register() error = %v", err)
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.r.Register()
+			err := e.Register(tt.r)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("RVExtensionRegistration.Register() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("Extension.Register() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,353 @@
+package a3interface
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// CommandSeparator separates the namespaces of a command, i.e. "db:query". Routers mounted under a prefix receive the commands that start with the prefix followed by this separator
+const CommandSeparator = ":"
+
+// Router matches commands received from Arma to registrations. Commands can be matched exactly, through an alias or through a pattern. A pattern is made of segments separated by CommandSeparator, where a segment can be:
+//
+//   - a literal, i.e. "db" in "db:query"
+//   - a glob as understood by path.Match, i.e. "get*" in "player:get*"
+//   - a named capture matching exactly one segment, i.e. "{id}" in "player:{id}:save"
+//   - a named capture matching all remaining segments as the final segment, i.e. "{rest...}" in "db:{rest...}"
+//
+// Captured segments are available from Request.Param
+type Router struct {
+	// routes are registrations with a literal command, matched exactly or through their aliases
+	routes []*RVExtensionRegistration
+	// patterns are registrations whose command contains a glob or capture
+	patterns []patternRoute
+	// mounts are sub-routers that receive commands starting with their prefix
+	mounts []mountedRouter
+	// fallback is called when no registration matches a command
+	fallback Handler
+	// caseInsensitive makes all matches ignore case
+	caseInsensitive bool
+	// middleware wraps the handlers of every registration matched through this router
+	middleware []Middleware
+}
+
+type patternRoute struct {
+	registration *RVExtensionRegistration
+	segments     []patternSegment
+}
+
+type patternSegment struct {
+	// value is the literal or glob text of the segment, or the capture name
+	value string
+	// capture is true for {name} and {name...} segments
+	capture bool
+	// rest is true for a final {name...} segment
+	rest bool
+}
+
+type mountedRouter struct {
+	prefix string
+	router *Router
+}
+
+// NewRouter returns an empty Router. Mount it under a prefix to route a group of commands to it
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// SetCaseInsensitive determines whether commands are matched regardless of case. Mounted routers also ignore case when their parent does
+func (rt *Router) SetCaseInsensitive(caseInsensitive bool) *Router {
+	rt.caseInsensitive = caseInsensitive
+	return rt
+}
+
+// SetFallback sets the Handler that is called synchronously when no registration matches a command
+func (rt *Router) SetFallback(handler Handler) *Router {
+	rt.fallback = handler
+	return rt
+}
+
+// Use adds middleware that wraps the handler of every registration matched through this router, including those of mounted routers and the fallback
+func (rt *Router) Use(middleware ...Middleware) *Router {
+	rt.middleware = append(rt.middleware, middleware...)
+	return rt
+}
+
+// Mount routes every command starting with prefix followed by CommandSeparator to sub, with the prefix and separator removed
+func (rt *Router) Mount(prefix string, sub *Router) error {
+	if prefix == "" {
+		return fmt.Errorf("mount prefix must not be empty")
+	}
+	if sub == nil || sub == rt {
+		return fmt.Errorf("invalid router mounted at %s", prefix)
+	}
+	for _, m := range rt.mounts {
+		if m.prefix == prefix {
+			return fmt.Errorf("a router is already mounted at %s", prefix)
+		}
+	}
+	rt.mounts = append(rt.mounts, mountedRouter{prefix: prefix, router: sub})
+	return nil
+}
+
+// Register adds a copy of the registration to this router
+func (rt *Router) Register(r *RVExtensionRegistration) error {
+	if r.Command == "" {
+		return fmt.Errorf("command must not be empty")
+	}
+	names := append([]string{r.Command}, r.Aliases...)
+	for _, name := range names {
+		if rt.isRegistered(name) {
+			return fmt.Errorf("command %s already registered", name)
+		}
+	}
+
+	registration := *r
+	if !isPattern(registration.Command) {
+		rt.routes = append(rt.routes, &registration)
+		return nil
+	}
+
+	if len(registration.Aliases) > 0 {
+		return fmt.Errorf("pattern %s cannot have aliases", registration.Command)
+	}
+	segments, err := parsePattern(registration.Command)
+	if err != nil {
+		return err
+	}
+	rt.patterns = append(rt.patterns, patternRoute{
+		registration: &registration,
+		segments:     segments,
+	})
+	return nil
+}
+
+// isRegistered returns true if name is already used as a command, alias or pattern in this router
+func (rt *Router) isRegistered(name string) bool {
+	for _, reg := range rt.routes {
+		if equalCommand(reg.Command, name, rt.caseInsensitive) {
+			return true
+		}
+		for _, alias := range reg.Aliases {
+			if equalCommand(alias, name, rt.caseInsensitive) {
+				return true
+			}
+		}
+	}
+	for _, p := range rt.patterns {
+		if equalCommand(p.registration.Command, name, rt.caseInsensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeMatch is the result of routing a command
+type routeMatch struct {
+	registration *RVExtensionRegistration
+	// params are the segments captured by the registration's pattern
+	params map[string]string
+	// middleware are the middleware of every router the command passed through, outermost first
+	middleware []Middleware
+	// prefix are the mount prefixes of the routers the command passed through, each followed by CommandSeparator
+	prefix string
+	// caseInsensitive is true if the registration was matched regardless of case
+	caseInsensitive bool
+}
+
+// command returns the full command of the matched registration, with the prefixes it is mounted under as listed by Router.Registrations
+func (m routeMatch) command() string {
+	return m.prefix + m.registration.Command
+}
+
+// aliases returns the full aliases of the matched registration, nil if it has none
+func (m routeMatch) aliases() []string {
+	if len(m.registration.Aliases) == 0 {
+		return nil
+	}
+	aliases := make([]string, len(m.registration.Aliases))
+	for index, alias := range m.registration.Aliases {
+		aliases[index] = m.prefix + alias
+	}
+	return aliases
+}
+
+// names returns the full command and aliases of the matched registration
+func (m routeMatch) names() []string {
+	return append([]string{m.command()}, m.aliases()...)
+}
+
+// Match returns the registration for a command and any segments captured by its pattern. If no registration matches, the fallback of the nearest router is returned as a registration. It returns nil if nothing matches
+func (rt *Router) Match(command string) (*RVExtensionRegistration, map[string]string) {
+	m := rt.route(command)
+	return m.registration, m.params
+}
+
+// route matches a command to a registration, falling back to the fallback of the nearest router
+func (rt *Router) route(command string) routeMatch {
+	if m := rt.lookup(command, false); m.registration != nil {
+		return m
+	}
+	return rt.fallbackFor(command, false)
+}
+
+// lookup matches a command to a registration, ignoring fallbacks
+func (rt *Router) lookup(command string, caseInsensitive bool) routeMatch {
+	caseInsensitive = caseInsensitive || rt.caseInsensitive
+	found := func(reg *RVExtensionRegistration, params map[string]string) routeMatch {
+		return routeMatch{
+			registration:    reg,
+			params:          params,
+			middleware:      rt.middleware,
+			caseInsensitive: caseInsensitive,
+		}
+	}
+
+	// exact commands and aliases
+	for _, reg := range rt.routes {
+		if equalCommand(reg.Command, command, caseInsensitive) {
+			return found(reg, nil)
+		}
+		for _, alias := range reg.Aliases {
+			if equalCommand(alias, command, caseInsensitive) {
+				return found(reg, nil)
+			}
+		}
+	}
+
+	// mounted routers
+	for _, m := range rt.mounts {
+		rest, ok := m.trim(command, caseInsensitive)
+		if !ok {
+			continue
+		}
+		if sub := m.router.lookup(rest, caseInsensitive); sub.registration != nil {
+			sub.middleware = joinMiddleware(rt.middleware, sub.middleware)
+			sub.prefix = m.prefix + CommandSeparator + sub.prefix
+			return sub
+		}
+	}
+
+	// patterns
+	segments := strings.Split(command, CommandSeparator)
+	for _, p := range rt.patterns {
+		if params, ok := matchPattern(p.segments, segments, caseInsensitive); ok {
+			return found(p.registration, params)
+		}
+	}
+	return routeMatch{}
+}
+
+// fallbackFor returns a registration for the fallback of the most deeply mounted router whose prefix matches the command, or an empty match if there is none
+func (rt *Router) fallbackFor(command string, caseInsensitive bool) routeMatch {
+	caseInsensitive = caseInsensitive || rt.caseInsensitive
+	for _, m := range rt.mounts {
+		rest, ok := m.trim(command, caseInsensitive)
+		if !ok {
+			continue
+		}
+		if sub := m.router.fallbackFor(rest, caseInsensitive); sub.registration != nil {
+			sub.registration.Command = command
+			sub.middleware = joinMiddleware(rt.middleware, sub.middleware)
+			return sub
+		}
+	}
+	if rt.fallback == nil {
+		return routeMatch{}
+	}
+	return routeMatch{
+		registration: &RVExtensionRegistration{
+			Command:  command,
+			Handler:  rt.fallback,
+			fallback: true,
+		},
+		middleware:      rt.middleware,
+		caseInsensitive: caseInsensitive,
+	}
+}
+
+// trim removes the mount prefix and separator from a command, returning false if the command is not routed to this mount
+func (m mountedRouter) trim(command string, caseInsensitive bool) (string, bool) {
+	prefix := m.prefix + CommandSeparator
+	if len(command) <= len(prefix) || !equalCommand(command[:len(prefix)], prefix, caseInsensitive) {
+		return "", false
+	}
+	return command[len(prefix):], true
+}
+
+func equalCommand(a, b string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// isPattern returns true if the command contains a glob or capture
+func isPattern(command string) bool {
+	return strings.ContainsAny(command, "{*?[")
+}
+
+func parsePattern(pattern string) ([]patternSegment, error) {
+	parts := strings.Split(pattern, CommandSeparator)
+	segments := make([]patternSegment, 0, len(parts))
+	for index, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			name := part[1 : len(part)-1]
+			rest := strings.HasSuffix(name, "...")
+			name = strings.TrimSuffix(name, "...")
+			if name == "" {
+				return nil, fmt.Errorf("pattern %s has an unnamed capture", pattern)
+			}
+			if rest && index != len(parts)-1 {
+				return nil, fmt.Errorf("pattern %s can only capture remaining segments at the end", pattern)
+			}
+			segments = append(segments, patternSegment{value: name, capture: true, rest: rest})
+			continue
+		}
+		if strings.ContainsAny(part, "{}") {
+			return nil, fmt.Errorf("pattern %s has an invalid capture %s", pattern, part)
+		}
+		if _, err := path.Match(part, ""); err != nil {
+			return nil, fmt.Errorf("pattern %s has an invalid glob %s: %s", pattern, part, err.Error())
+		}
+		segments = append(segments, patternSegment{value: part})
+	}
+	return segments, nil
+}
+
+func matchPattern(pattern []patternSegment, segments []string, caseInsensitive bool) (map[string]string, bool) {
+	params := make(map[string]string)
+	for index, p := range pattern {
+		if p.rest {
+			if index >= len(segments) {
+				return nil, false
+			}
+			params[p.value] = strings.Join(segments[index:], CommandSeparator)
+			return params, true
+		}
+		if index >= len(segments) {
+			return nil, false
+		}
+		segment := segments[index]
+		if p.capture {
+			if segment == "" {
+				return nil, false
+			}
+			params[p.value] = segment
+			continue
+		}
+		value := p.value
+		if caseInsensitive {
+			value = strings.ToLower(value)
+			segment = strings.ToLower(segment)
+		}
+		if ok, _ := path.Match(value, segment); !ok {
+			return nil, false
+		}
+	}
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	return params, true
+}
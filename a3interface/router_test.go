@@ -0,0 +1,119 @@
+package a3interface
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRouter_Match(t *testing.T) {
+	fallback := HandlerFunc(func(req *Request) error { return nil })
+
+	db := NewRouter().SetFallback(fallback)
+	NewRegistration("query").RegisterTo(db)
+	NewRegistration("exec").SetAliases("run").RegisterTo(db)
+
+	router := NewRouter()
+	NewRegistration("test").RegisterTo(router)
+	NewRegistration("player:{id}:save").RegisterTo(router)
+	NewRegistration("log:*").RegisterTo(router)
+	NewRegistration("files:{path...}").RegisterTo(router)
+	router.Mount("db", db)
+
+	insensitive := NewRouter().SetCaseInsensitive(true)
+	insensitive.Mount("db", db)
+
+	tests := []struct {
+		name        string
+		router      *Router
+		command     string
+		wantCommand string
+		wantParams  map[string]string
+	}{
+		{
+			name:        "exact",
+			router:      router,
+			command:     "test",
+			wantCommand: "test",
+		},
+		{
+			name:    "case sensitive",
+			router:  router,
+			command: "TEST",
+		},
+		{
+			name:        "mounted",
+			router:      router,
+			command:     "db:query",
+			wantCommand: "query",
+		},
+		{
+			name:        "mounted alias",
+			router:      router,
+			command:     "db:run",
+			wantCommand: "exec",
+		},
+		{
+			name:        "mounted fallback",
+			router:      router,
+			command:     "db:missing",
+			wantCommand: "db:missing",
+		},
+		{
+			name:        "capture",
+			router:      router,
+			command:     "player:76561198000000000:save",
+			wantCommand: "player:{id}:save",
+			wantParams:  map[string]string{"id": "76561198000000000"},
+		},
+		{
+			name:        "glob",
+			router:      router,
+			command:     "log:warning",
+			wantCommand: "log:*",
+			wantParams:  map[string]string{},
+		},
+		{
+			name:    "glob extra segment",
+			router:  router,
+			command: "log:warning:extra",
+		},
+		{
+			name:        "capture remaining",
+			router:      router,
+			command:     "files:a:b:c",
+			wantCommand: "files:{path...}",
+			wantParams:  map[string]string{"path": "a:b:c"},
+		},
+		{
+			name:    "not registered",
+			router:  router,
+			command: "missing",
+		},
+		{
+			name:        "case insensitive",
+			router:      insensitive,
+			command:     "DB:Query",
+			wantCommand: "query",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, params := tt.router.Match(tt.command)
+			if tt.wantCommand == "" {
+				if got != nil {
+					t.Errorf("Router.Match() = %v, want nil", got.Command)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("Router.Match() = nil, want %v", tt.wantCommand)
+			}
+			if got.Command != tt.wantCommand {
+				t.Errorf("Router.Match() = %v, want %v", got.Command, tt.wantCommand)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("Router.Match() params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
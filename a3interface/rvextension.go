@@ -7,38 +7,15 @@ package a3interface
 */
 import "C"
 import (
-	"fmt"
-	"strings"
+	"log/slog"
 	"unsafe"
 )
 
-var activeContext *ArmaExtensionContext
-
-// Config is the central configuration used by this library
-var config *configStruct = new(configStruct)
-
-func init() {
-	// initialize the config struct
-	config.init()
-
-	// set the default version
-	config.version = "DEVELOPMENT"
-
-	// init blank active context
-	activeContext = &ArmaExtensionContext{
-		SteamID:           "123456789",
-		FileSource:        "test",
-		MissionNameSource: "test",
-		ServerName:        "test",
-	}
-
-}
-
 // called by Arma to get the version of the extension
 //
 //export RVExtensionVersion
 func RVExtensionVersion(output *C.char, outputsize C.size_t) {
-	replyToSyncArmaCall(config.version, output, outputsize)
+	replyToSyncArmaCall(defaultExtension.Version(), output, outputsize)
 }
 
 type ArmaExtensionContext struct {
@@ -46,6 +23,8 @@ type ArmaExtensionContext struct {
 	FileSource        string
 	MissionNameSource string
 	ServerName        string
+	// RemoteExecutedOwner is the machine network ID of the client that remote executed the call, or "0" if it was not remote executed. It is empty for game versions that don't send it
+	RemoteExecutedOwner string
 }
 
 // passed just before all calls of exported functions
@@ -62,114 +41,37 @@ func RVExtensionContext(args **C.char, argsCnt C.int) {
 		args = (**C.char)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + offset))
 	}
 
-	activeContext = &ArmaExtensionContext{
-		SteamID:           data[0],
-		FileSource:        data[1],
-		MissionNameSource: data[2],
-		ServerName:        data[3],
+	// older game versions send fewer arguments
+	for len(data) < 5 {
+		data = append(data, "")
+	}
+	ctx := ArmaExtensionContext{
+		SteamID:             data[0],
+		FileSource:          data[1],
+		MissionNameSource:   data[2],
+		ServerName:          data[3],
+		RemoteExecutedOwner: data[4],
 	}
-	fmt.Printf("RVExtensionContext: %+v\n", activeContext)
+	defaultExtension.SetContext(ctx)
+	defaultExtension.Logger().Debug("context received",
+		slog.String("mission", ctx.MissionNameSource),
+		slog.String("server", ctx.ServerName),
+		slog.String("file_source", ctx.FileSource),
+	)
 }
 
 // called by Arma when in the format of: "extensionName" callExtension "command"
 //
 //export RVExtension
 func RVExtension(output *C.char, outputsize C.size_t, input *C.char) {
-
-	var command string = C.GoString(input)
-	var commandSubstr string = strings.Split(command, "|")[0]
-
-	fmt.Println("command: ", command)
-	fmt.Println("commandSubstr: ", commandSubstr)
-
-	// look for registration
-	registration := config.getRegistration(command)
-	if registration == nil {
-		registration = config.getRegistration(commandSubstr)
-		if registration == nil {
-			writeErrChan(command, fmt.Errorf("command not registered"))
-			replyToSyncArmaCall(
-				fmt.Sprintf(`["Command %s not registered!"]`, command),
-				output, outputsize)
-			return
-		}
-	}
-
-	fmt.Printf("registration: %+v\n", registration)
-	fmt.Printf("runInBackground: %t\n", registration.RunInBackground)
-
-	// if RunInBackground is true for this registration, send default response
-	// to Arma and run the function in the background
-	// data can be sent back to arma using WriteArmaCallback
-	if registration.RunInBackground {
-		replyToSyncArmaCall(registration.DefaultResponse, output, outputsize)
-	}
-
-	// get function pointer
-	fnc := registration.Function
-	if fnc == nil {
-		writeErrChan(command, fmt.Errorf("function not set"))
-		replyToSyncArmaCall(
-			fmt.Sprintf(`["RVExtension function not set for command %s"]`, command),
-			output, outputsize)
-		return
-	}
-
-	// if running in background, launch the function in an asynchronous goroutine and return
-	if registration.RunInBackground {
-		go func() {
-			_, err := (fnc)(*activeContext, command)
-			if err != nil {
-				writeErrChan(command, err)
-			}
-		}()
-		return
-	}
-
-	// otherwise, Arma is awaiting a reply
-	response, err := (fnc)(*activeContext, command)
-	if err != nil {
-		writeErrChan(command, err)
-		replyToSyncArmaCall(
-			fmt.Sprintf(
-				`[%q, %q]`,
-				command,
-				fmt.Sprintf("Error: %q", err.Error()),
-			),
-			output, outputsize)
-		return
-	} else {
-		replyToSyncArmaCall(response, output, outputsize)
-	}
+	response := defaultExtension.Call(C.GoString(input), int(outputsize))
+	replyToSyncArmaCall(response, output, outputsize)
 }
 
 // called by Arma when in the format of: "extensionName" callExtension ["command", ["data"]]
 //
 //export RVExtensionArgs
 func RVExtensionArgs(output *C.char, outputsize C.size_t, input *C.char, argv **C.char, argc C.int) {
-
-	// get command as Go string
-	command := C.GoString(input)
-
-	// look for registration
-	registration := config.getRegistration(command)
-	if registration == nil {
-		writeErrChan(command, fmt.Errorf("command not registered"))
-		replyToSyncArmaCall(
-			fmt.Sprintf(`["Command %s not registered!"]`, command),
-			output, outputsize)
-		return
-	}
-
-	// if RunInBackground is true for this registration, send default response
-	// to Arma and run the function in the background
-	// data can be sent back to arma using WriteArmaCallback
-	// do this so parsing arguments doesn't block Arma
-	if registration.RunInBackground {
-		replyToSyncArmaCall(registration.DefaultResponse, output, outputsize)
-	}
-
-	// now, we'll process the data
 	// process the C vector into a Go slice
 	var offset = unsafe.Sizeof(uintptr(0))
 	var data []string
@@ -178,47 +80,6 @@ func RVExtensionArgs(output *C.char, outputsize C.size_t, input *C.char, argv **
 		argv = (**C.char)(unsafe.Pointer(uintptr(unsafe.Pointer(argv)) + offset))
 	}
 
-	command = RemoveEscapeQuotes(command)
-	for index, item := range data {
-		data[index] = RemoveEscapeQuotes(item)
-	}
-
-	// get function pointer
-	fnc := registration.ArgsFunction
-	if fnc == nil {
-		writeErrChan(command, fmt.Errorf("function not set"))
-		replyToSyncArmaCall(
-			fmt.Sprintf(`["RVExtensionArgs function not set for command %s"]`, command),
-			output, outputsize)
-		return
-	}
-
-	// if running in background, launch the function in an asynchronous goroutine and return
-	if registration.RunInBackground {
-		go func() {
-			_, err := (fnc)(*activeContext, command, data)
-			if err != nil {
-				writeErrChan(command, err)
-			}
-		}()
-		return
-	}
-
-	// otherwise, Arma is awaiting a reply
-	response, err := (fnc)(*activeContext, command, data)
-	if err != nil {
-		writeErrChan(command, err)
-		replyToSyncArmaCall(
-			fmt.Sprintf(
-				`[%q, %q]`,
-				command,
-				fmt.Sprintf(
-					"Error: %s",
-					err.Error()),
-			),
-			output, outputsize)
-		return
-	} else {
-		replyToSyncArmaCall(response, output, outputsize)
-	}
+	response := defaultExtension.CallArgs(C.GoString(input), data, int(outputsize))
+	replyToSyncArmaCall(response, output, outputsize)
 }
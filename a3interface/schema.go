@@ -0,0 +1,225 @@
+package a3interface
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ArgType is the SQF type expected for an argument
+type ArgType string
+
+const (
+	// ArgAny accepts any value
+	ArgAny ArgType = "any"
+	// ArgString expects an SQF string
+	ArgString ArgType = "string"
+	// ArgNumber expects an SQF number
+	ArgNumber ArgType = "number"
+	// ArgBool expects an SQF boolean
+	ArgBool ArgType = "bool"
+	// ArgArray expects an SQF array
+	ArgArray ArgType = "array"
+	// ArgHashMap expects an SQF hashmap sent as an array of key-value pairs
+	ArgHashMap ArgType = "hashmap"
+)
+
+// ArgSpec describes a single positional argument
+type ArgSpec struct {
+	// Name is used in error messages and documentation
+	Name string `json:"name"`
+	// Type is the expected SQF type
+	Type ArgType `json:"type"`
+	// Optional arguments may be left out, but only if every argument after them is left out as well
+	Optional bool `json:"optional,omitempty"`
+	// Min is the minimum value of a number, or the minimum length of a string, array or hashmap
+	Min *float64 `json:"min,omitempty"`
+	// Max is the maximum value of a number, or the maximum length of a string, array or hashmap
+	Max *float64 `json:"max,omitempty"`
+}
+
+// ArgSchema describes the arguments a command accepts. It is validated by the dispatcher before the handler is called and can be marshalled to JSON for documentation
+type ArgSchema struct {
+	// Args are the positional arguments, in order
+	Args []ArgSpec `json:"args"`
+	// Variadic allows more arguments than are declared. Extra arguments are not validated
+	Variadic bool `json:"variadic,omitempty"`
+}
+
+// NewArgSchema returns an empty schema, which accepts no arguments
+func NewArgSchema() *ArgSchema {
+	return &ArgSchema{
+		Args: make([]ArgSpec, 0),
+	}
+}
+
+// Arg adds a required argument
+func (s *ArgSchema) Arg(name string, argType ArgType) *ArgSchema {
+	s.Args = append(s.Args, ArgSpec{Name: name, Type: argType})
+	return s
+}
+
+// OptionalArg adds an optional argument
+func (s *ArgSchema) OptionalArg(name string, argType ArgType) *ArgSchema {
+	s.Args = append(s.Args, ArgSpec{Name: name, Type: argType, Optional: true})
+	return s
+}
+
+// Range sets the minimum and maximum of the last added argument. For strings, arrays and hashmaps the range applies to their length
+func (s *ArgSchema) Range(min float64, max float64) *ArgSchema {
+	if len(s.Args) == 0 {
+		return s
+	}
+	s.Args[len(s.Args)-1].Min = &min
+	s.Args[len(s.Args)-1].Max = &max
+	return s
+}
+
+// SetVariadic determines whether more arguments than are declared are accepted
+func (s *ArgSchema) SetVariadic(variadic bool) *ArgSchema {
+	s.Variadic = variadic
+	return s
+}
+
+// required returns the number of arguments that must be present
+func (s *ArgSchema) required() int {
+	required := 0
+	for index, spec := range s.Args {
+		if !spec.Optional {
+			required = index + 1
+		}
+	}
+	return required
+}
+
+// ArgError describes an argument that failed validation
+type ArgError struct {
+	// Index is the zero-based position of the argument
+	Index int
+	// Name is the name of the argument in the schema
+	Name string
+	// Message describes why the argument was rejected
+	Message string
+}
+
+// ValidationError is returned when a call's arguments do not match the registration's schema
+type ValidationError struct {
+	Command string
+	// Count is set if the wrong number of arguments was sent
+	Count string
+	// Args are the individual arguments that were rejected
+	Args []ArgError
+}
+
+func (e *ValidationError) Error() string {
+	var problems []string
+	if e.Count != "" {
+		problems = append(problems, e.Count)
+	}
+	for _, arg := range e.Args {
+		problems = append(problems, fmt.Sprintf("argument %d (%s) %s", arg.Index+1, arg.Name, arg.Message))
+	}
+	return fmt.Sprintf("invalid arguments for %s: %s", e.Command, strings.Join(problems, "; "))
+}
+
+// details returns the problems as [index, name, message] arrays, with a count problem at index -1
+func (e *ValidationError) details() []interface{} {
+	details := make([]interface{}, 0, len(e.Args)+1)
+	if e.Count != "" {
+		details = append(details, []interface{}{-1, "", e.Count})
+	}
+	for _, arg := range e.Args {
+		details = append(details, []interface{}{arg.Index, arg.Name, arg.Message})
+	}
+	return details
+}
+
+// Validate checks raw arguments as received from Arma against the schema. It returns the arguments parsed into Go values: string, float64, bool, []interface{} or map[string]interface{}. Arguments sent in the "command|data" format are untyped, so strings are only checked for quotes in the ["command", ["data"]] format
+func (s *ArgSchema) Validate(command string, form CallForm, rawArgs []string) ([]interface{}, error) {
+	verr := &ValidationError{Command: command}
+
+	if required := s.required(); len(rawArgs) < required {
+		verr.Count = fmt.Sprintf("expected at least %d arguments, got %d", required, len(rawArgs))
+	} else if !s.Variadic && len(rawArgs) > len(s.Args) {
+		verr.Count = fmt.Sprintf("expected at most %d arguments, got %d", len(s.Args), len(rawArgs))
+	}
+
+	values := make([]interface{}, len(rawArgs))
+	for index, raw := range rawArgs {
+		if index >= len(s.Args) {
+			values[index] = RemoveEscapeQuotes(raw)
+			continue
+		}
+		spec := s.Args[index]
+		value, err := spec.parse(form, raw)
+		if err != nil {
+			verr.Args = append(verr.Args, ArgError{Index: index, Name: spec.Name, Message: err.Error()})
+			continue
+		}
+		values[index] = value
+	}
+
+	if verr.Count != "" || len(verr.Args) > 0 {
+		return nil, verr
+	}
+	return values, nil
+}
+
+// parse converts a raw argument to the Go value of its type, checking its range
+func (spec ArgSpec) parse(form CallForm, raw string) (interface{}, error) {
+	var (
+		value  interface{}
+		length float64
+	)
+	switch spec.Type {
+	case ArgString:
+		if form == CallFormArgs && !(len(raw) >= 2 && strings.HasPrefix(raw, `"`) && strings.HasSuffix(raw, `"`)) {
+			return nil, fmt.Errorf("must be a string, got %s", raw)
+		}
+		str := RemoveEscapeQuotes(raw)
+		value, length = str, float64(len(str))
+	case ArgNumber:
+		number, err := strconv.ParseFloat(strings.TrimSpace(RemoveEscapeQuotes(raw)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number, got %s", raw)
+		}
+		value, length = number, number
+	case ArgBool:
+		b, err := strconv.ParseBool(strings.ToLower(strings.TrimSpace(RemoveEscapeQuotes(raw))))
+		if err != nil {
+			return nil, fmt.Errorf("must be a bool, got %s", raw)
+		}
+		return b, nil
+	case ArgArray:
+		parsed, err := ParseSQF(raw)
+		array, ok := parsed.([]interface{})
+		if err != nil || !ok {
+			return nil, fmt.Errorf("must be an array, got %s", raw)
+		}
+		value, length = array, float64(len(array))
+	case ArgHashMap:
+		parsed, err := ParseSQF(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a hashmap, got %s", raw)
+		}
+		hashMap, err := ParseSQFHashMap(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("must be a hashmap, got %s", raw)
+		}
+		value, length = hashMap, float64(len(hashMap))
+	default:
+		return RemoveEscapeQuotes(raw), nil
+	}
+
+	unit := ""
+	if spec.Type != ArgNumber {
+		unit = " in length"
+	}
+	if spec.Min != nil && length < *spec.Min {
+		return nil, fmt.Errorf("must be at least %v%s, got %v", *spec.Min, unit, length)
+	}
+	if spec.Max != nil && length > *spec.Max {
+		return nil, fmt.Errorf("must be at most %v%s, got %v", *spec.Max, unit, length)
+	}
+	return value, nil
+}
@@ -0,0 +1,136 @@
+package a3interface
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgSchema_Validate(t *testing.T) {
+	schema := NewArgSchema().
+		Arg("name", ArgString).Range(1, 8).
+		Arg("score", ArgNumber).Range(0, 100).
+		OptionalArg("tags", ArgArray).
+		OptionalArg("extra", ArgHashMap)
+
+	type args struct {
+		form    CallForm
+		rawArgs []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			name: "required only",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`, `29`},
+			},
+			want: []interface{}{"Rick", 29.0},
+		},
+		{
+			name: "all arguments",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`, `29`, `["a",1]`, `[["key",true]]`},
+			},
+			want: []interface{}{
+				"Rick",
+				29.0,
+				[]interface{}{"a", 1.0},
+				map[string]interface{}{"key": true},
+			},
+		},
+		{
+			name: "string form is untyped",
+			args: args{
+				form:    CallFormString,
+				rawArgs: []string{`Rick`, `29`},
+			},
+			want: []interface{}{"Rick", 29.0},
+		},
+		{
+			name: "missing argument",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many arguments",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`, `29`, `[]`, `[]`, `1`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unquoted string",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`29`, `29`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a number",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`, `"abc"`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "number out of range",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`, `101`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "string too long",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick Astley"`, `1`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a hashmap",
+			args: args{
+				form:    CallFormArgs,
+				rawArgs: []string{`"Rick"`, `1`, `[]`, `[1, 2]`},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schema.Validate("test", tt.args.form, tt.args.rawArgs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ArgSchema.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ArgSchema.Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgSchema_Validate_envelope(t *testing.T) {
+	schema := NewArgSchema().Arg("score", ArgNumber)
+	_, err := schema.Validate("setScore", CallFormArgs, []string{`"high"`})
+	if err == nil {
+		t.Fatal("ArgSchema.Validate() error = nil, want error")
+	}
+
+	want := `["error", "INVALID_ARGS", "invalid arguments for setScore: argument 1 (score) must be a number, got ""high""", [[0, "score", "must be a number, got ""high"""]], false]`
+	if got := AsError(err).SQF(); got != want {
+		t.Errorf("AsError().SQF() = %v, want %v", got, want)
+	}
+}
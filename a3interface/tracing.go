@@ -0,0 +1,255 @@
+package a3interface
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span status codes, matching the OpenTelemetry status codes
+const (
+	SpanStatusUnset = "STATUS_CODE_UNSET"
+	SpanStatusOK    = "STATUS_CODE_OK"
+	SpanStatusError = "STATUS_CODE_ERROR"
+)
+
+// SpanStatus is the outcome of a span
+type SpanStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// SpanData is a finished span, in a shape compatible with the OpenTelemetry span model. IDs are lowercase hex
+type SpanData struct {
+	TraceID           string                 `json:"traceId"`
+	SpanID            string                 `json:"spanId"`
+	ParentSpanID      string                 `json:"parentSpanId,omitempty"`
+	Name              string                 `json:"name"`
+	StartTimeUnixNano int64                  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64                  `json:"endTimeUnixNano"`
+	Attributes        map[string]interface{} `json:"attributes,omitempty"`
+	Status            SpanStatus             `json:"status"`
+}
+
+// SpanExporter receives every span once it has ended. It is called from the goroutine that ended the span, so it should not block for long
+type SpanExporter interface {
+	ExportSpan(span SpanData) error
+}
+
+// Span is an operation being traced. A nil *Span is valid and does nothing, which is what StartSpan returns when tracing is disabled
+type Span struct {
+	mu       sync.Mutex
+	data     SpanData
+	start    time.Time
+	ended    bool
+	exporter SpanExporter
+	// logger receives errors exporting the span
+	logger *slog.Logger
+}
+
+type spanContextKey struct{}
+
+// SetSpanExporter enables tracing and sends every finished span to exporter. Pass nil to disable tracing
+func (e *Extension) SetSpanExporter(exporter SpanExporter) {
+	if exporter == nil {
+		e.spanExporter.Store(nil)
+		return
+	}
+	e.spanExporter.Store(&exporter)
+}
+
+// StartSpan starts a span as a child of the span in ctx, exported like its parent, or as a new trace of the default Extension if there is none. It returns a context holding the new span, which must be ended with End. If tracing is disabled, the returned span is nil
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	if parent := SpanFromContext(ctx); parent != nil {
+		return startSpan(ctx, name, parent.exporter, parent.logger)
+	}
+	return defaultExtension.StartSpan(ctx, name)
+}
+
+// StartSpan starts a span as a child of the span in ctx, or as a new trace if there is none. It returns a context holding the new span, which must be ended with End. If tracing is disabled, the returned span is nil
+func (e *Extension) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	var exporter SpanExporter
+	if p := e.spanExporter.Load(); p != nil {
+		exporter = *p
+	}
+	return startSpan(ctx, name, exporter, e.Logger())
+}
+
+func startSpan(ctx context.Context, name string, exporter SpanExporter, logger *slog.Logger) (context.Context, *Span) {
+	if exporter == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		start:    time.Now(),
+		exporter: exporter,
+		logger:   logger,
+		data: SpanData{
+			SpanID:     newID(8),
+			Name:       name,
+			Attributes: make(map[string]interface{}),
+			Status:     SpanStatus{Code: SpanStatusUnset},
+		},
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = newID(16)
+	}
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// SpanFromContext returns the span held by ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// TraceID returns the trace ID of the span, or an empty string for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.TraceID
+}
+
+// SetAttribute sets an attribute on the span. Values should be strings, numbers or bools
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.data.Attributes[key] = value
+}
+
+// SetError marks the span as failed if err is not nil, or as successful otherwise
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.data.Status = SpanStatus{Code: SpanStatusError, Message: err.Error()}
+		return
+	}
+	s.data.Status = SpanStatus{Code: SpanStatusOK}
+}
+
+// End finishes the span and exports it. Calling End more than once has no effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.StartTimeUnixNano = s.start.UnixNano()
+	s.data.EndTimeUnixNano = time.Now().UnixNano()
+	data := s.data
+	s.mu.Unlock()
+
+	if err := s.exporter.ExportSpan(data); err != nil {
+		s.logger.Warn("error exporting span", "span", data.Name, "error", err.Error())
+	}
+}
+
+// newID returns a random ID of n bytes as lowercase hex
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// fall back to the time, which is unique enough for local traces
+		return fmt.Sprintf("%0*x", n*2, time.Now().UnixNano())[:n*2]
+	}
+	return hex.EncodeToString(b)
+}
+
+// startCallSpan starts the span for a call from Arma
+func startCallSpan(req *Request, background bool) *Span {
+	name := req.Pattern
+	if name == "" {
+		name = req.Command
+	}
+	ctx, span := req.Extension().StartSpan(req.Context(), name)
+	if span == nil {
+		return nil
+	}
+	span.SetAttribute("a3go.command", req.Command)
+	span.SetAttribute("a3go.pattern", req.Pattern)
+	span.SetAttribute("a3go.form", req.Form.String())
+	span.SetAttribute("a3go.background", background)
+	span.SetAttribute("arma.steam_id", req.ArmaContext.SteamID)
+	span.SetAttribute("arma.mission", req.ArmaContext.MissionNameSource)
+	span.SetAttribute("arma.server", req.ArmaContext.ServerName)
+	span.SetAttribute("arma.file_source", req.ArmaContext.FileSource)
+	span.SetAttribute("arma.remote_executed_owner", req.ArmaContext.RemoteExecutedOwner)
+	req.ctx = ctx
+	return span
+}
+
+// withTraceID appends the trace ID of the request as the last element of an SQF array response
+func withTraceID(req *Request, response string) string {
+	traceID := SpanFromContext(req.Context()).TraceID()
+	trimmed := strings.TrimSpace(response)
+	if traceID == "" || !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+		return response
+	}
+	inner := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+	if inner == "" {
+		return fmt.Sprintf(`["%s"]`, traceID)
+	}
+	return fmt.Sprintf(`[%s, "%s"]`, inner, traceID)
+}
+
+// JSONLinesExporter is a SpanExporter that writes each span as a line of JSON
+type JSONLinesExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewJSONLinesExporter returns a SpanExporter that writes each span as a line of JSON to w
+func NewJSONLinesExporter(w io.Writer) *JSONLinesExporter {
+	return &JSONLinesExporter{encoder: json.NewEncoder(w)}
+}
+
+// NewJSONLinesFileExporter returns a SpanExporter that appends each span as a line of JSON to the file at path, for offline analysis
+func NewJSONLinesFileExporter(path string) (*JSONLinesExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening trace file: %s", err.Error())
+	}
+	exporter := NewJSONLinesExporter(file)
+	exporter.closer = file
+	return exporter, nil
+}
+
+// ExportSpan writes the span as a line of JSON
+func (e *JSONLinesExporter) ExportSpan(span SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.encoder.Encode(span)
+}
+
+// Close closes the underlying file if the exporter was created with NewJSONLinesFileExporter
+func (e *JSONLinesExporter) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
@@ -0,0 +1,87 @@
+package a3interface
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *recordingExporter) ExportSpan(span SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	e := NewExtension()
+	exporter := &recordingExporter{}
+	e.SetSpanExporter(exporter)
+
+	err := e.Register(NewRegistration("tracingAsync").
+		SetRunInBackground(true).
+		SetHandler(HandlerFunc(func(req *Request) error {
+			_, span := StartSpan(req.Context(), "db.query")
+			span.SetAttribute("db.table", "players")
+			span.End()
+			return nil
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	response := e.dispatch(CallFormArgs, "tracingAsync", nil, 10240)
+
+	// the call span ends after the background handler returns
+	deadline := time.Now().Add(time.Second)
+	exporter.mu.Lock()
+	for len(exporter.spans) < 2 && time.Now().Before(deadline) {
+		exporter.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		exporter.mu.Lock()
+	}
+	defer exporter.mu.Unlock()
+	if len(exporter.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(exporter.spans))
+	}
+	child, call := exporter.spans[0], exporter.spans[1]
+	if call.Name != "tracingAsync" || call.ParentSpanID != "" {
+		t.Errorf("call span = %+v, want root span tracingAsync", call)
+	}
+	if child.TraceID != call.TraceID || child.ParentSpanID != call.SpanID {
+		t.Errorf("child span = %+v, want child of %s", child, call.SpanID)
+	}
+	if call.Attributes["a3go.command"] != "tracingAsync" || call.Status.Code != SpanStatusOK {
+		t.Errorf("call span = %+v, want command attribute and OK status", call)
+	}
+
+	want := fmt.Sprintf(`["Command tracingAsync called", "%s"]`, call.TraceID)
+	if response != want {
+		t.Errorf("dispatch() = %v, want %v", response, want)
+	}
+}
+
+func TestJSONLinesExporter_ExportSpan(t *testing.T) {
+	var b bytes.Buffer
+	exporter := NewJSONLinesExporter(&b)
+	span := SpanData{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331", Name: "test"}
+	if err := exporter.ExportSpan(span); err != nil {
+		t.Fatalf("ExportSpan() error = %v", err)
+	}
+
+	var got SpanData
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got.TraceID != span.TraceID || got.SpanID != span.SpanID || got.Name != span.Name {
+		t.Errorf("exported %+v, want %+v", got, span)
+	}
+}
@@ -20,29 +20,24 @@ func replyToSyncArmaCall(
 	outputsize C.size_t,
 ) {
 	// Reply to a synchronous call from Arma with a string response
+	if outputsize == 0 {
+		return
+	}
 	result := C.CString(response)
 	defer C.free(unsafe.Pointer(result))
-	var size = C.strlen(result) + 1
-	if size > outputsize {
-		size = outputsize
+	// leave room for the null terminator if the response has to be truncated
+	var size = C.strlen(result)
+	if size > outputsize-1 {
+		size = outputsize - 1
 	}
 	ptr := C.memmove(unsafe.Pointer(output), unsafe.Pointer(result), size)
 	if ptr == nil {
-		fmt.Println("Error copying string to output")
+		defaultExtension.Logger().Error("error copying string to output")
 	}
+	*(*C.char)(unsafe.Pointer(uintptr(unsafe.Pointer(output)) + uintptr(size))) = 0
 
 }
 
-// writeErrChan will write an error to the error channel for a command
-func writeErrChan(command string, err error) {
-	if config.errChan == nil {
-		return
-	}
-	go func() {
-		config.errChan <- []string{command, err.Error()}
-	}()
-}
-
 func getTimestamp() string {
 	// get the current unix timestamp in nanoseconds
 	return fmt.Sprintf("%d", time.Now().UTC().UnixNano())
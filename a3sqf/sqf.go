@@ -0,0 +1,561 @@
+// Package a3sqf generates the SQF side of an extension from its registrations, so it can't drift from the Go side: a wrapper function for every command that validates its arguments against the command's ArgSchema before calling it, an ExtensionCallback dispatcher, and a config.cpp declaring them in CfgFunctions. The files make up a HEMTT addon:
+//
+//	config.cpp
+//	$PBOPREFIX$
+//	functions/fn_callExtension.sqf
+//	functions/fn_extensionCallback.sqf
+//	functions/fn_<command>.sqf
+package a3sqf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/indig0fox/a3go/a3interface"
+)
+
+// Options configures the generated addon
+type Options struct {
+	// Extension is the name the extension is called by with callExtension
+	Extension string
+	// Prefix is the prefix of the HEMTT project. It is the CfgFunctions tag, so functions are named <Prefix>_fnc_<command>
+	Prefix string
+	// MainPrefix is the main prefix of the HEMTT project, "x" if empty
+	MainPrefix string
+	// Component is the name of the generated addon, "commands" if empty
+	Component string
+	// Callbacks are the callback functions the extension sends besides those declared with SetCallbacks, of background commands and of time budgets, with a description, i.e. the function passed to NewCallbackLogHandler
+	Callbacks map[string]string
+}
+
+// Functions of the generated addon that aren't command wrappers
+const (
+	// CallExtensionFunction calls a command and unwraps its response
+	CallExtensionFunction = "callExtension"
+	// ExtensionCallbackFunction adds the ExtensionCallback dispatcher at postInit
+	ExtensionCallbackFunction = "extensionCallback"
+)
+
+// identifier matches the characters SQF allows in function and variable names
+var identifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// command is a registration as seen by a wrapper
+type command struct {
+	reg      a3interface.RVExtensionRegistration
+	function string
+	args     []arg
+}
+
+// arg is an argument of a wrapper
+type arg struct {
+	index int
+	spec  a3interface.ArgSpec
+	// variable is the SQF variable the argument is passed in
+	variable string
+}
+
+// callback is a callback function the dispatcher accepts
+type callback struct {
+	function    string
+	description string
+}
+
+// Generate returns the files of the addon for registrations, keyed by their path relative to the addon directory. Pattern commands can't be called by a fixed name and get no wrapper
+func Generate(registrations []a3interface.RVExtensionRegistration, opts Options) (map[string][]byte, error) {
+	if opts.Extension == "" {
+		return nil, errors.New("missing extension name")
+	}
+	if opts.Prefix == "" || identifier.MatchString(opts.Prefix) {
+		return nil, fmt.Errorf("invalid prefix %q", opts.Prefix)
+	}
+	if opts.MainPrefix == "" {
+		opts.MainPrefix = "x"
+	}
+	if opts.Component == "" {
+		opts.Component = "commands"
+	}
+
+	functions := map[string]string{
+		strings.ToLower(CallExtensionFunction):     "",
+		strings.ToLower(ExtensionCallbackFunction): "",
+	}
+	var commands []command
+	callbacks := map[string]string{}
+	for function, description := range opts.Callbacks {
+		callbacks[function] = description
+	}
+	for _, reg := range registrations {
+		if strings.Contains(reg.Command, "{") {
+			continue
+		}
+		c := command{reg: reg, function: identifier.ReplaceAllString(reg.Command, "_")}
+		// CfgFunctions class names are case insensitive
+		if other, ok := functions[strings.ToLower(c.function)]; ok {
+			if other == "" {
+				return nil, fmt.Errorf("command %s can't have a wrapper, %s_fnc_%s is generated", reg.Command, opts.Prefix, c.function)
+			}
+			return nil, fmt.Errorf("commands %s and %s both have the wrapper %s_fnc_%s", other, reg.Command, opts.Prefix, c.function)
+		}
+		functions[strings.ToLower(c.function)] = reg.Command
+		if reg.ArgSchema != nil {
+			c.args = wrapperArgs(reg.ArgSchema)
+		}
+		commands = append(commands, c)
+
+		for _, spec := range reg.Callbacks {
+			callbacks[spec.Function] = spec.Description
+		}
+		// background commands that don't declare their callbacks are assumed to send them under their own name
+		if _, ok := callbacks[reg.Command]; reg.RunInBackground && len(reg.Callbacks) == 0 && !ok {
+			callbacks[reg.Command] = fmt.Sprintf("the results of the background command %q, if it sends any", reg.Command)
+		}
+		if reg.TimeBudget > 0 {
+			callbacks[a3interface.PendingCallbackFunction] = "[jobID, response] of calls that exceeded their time budget"
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].function < commands[j].function })
+	var sortedCallbacks []callback
+	for function, description := range callbacks {
+		sortedCallbacks = append(sortedCallbacks, callback{function: function, description: description})
+	}
+	sort.Slice(sortedCallbacks, func(i, j int) bool { return sortedCallbacks[i].function < sortedCallbacks[j].function })
+
+	files := map[string][]byte{
+		"config.cpp":                            []byte(configCpp(commands, opts)),
+		"$PBOPREFIX$":                           []byte(fmt.Sprintf(`%s\%s\addons\%s`, opts.MainPrefix, opts.Prefix, opts.Component)),
+		functionPath(CallExtensionFunction):     []byte(callExtensionSQF(opts)),
+		functionPath(ExtensionCallbackFunction): []byte(extensionCallbackSQF(sortedCallbacks, opts)),
+	}
+	for _, c := range commands {
+		files[functionPath(c.function)] = []byte(wrapperSQF(c, opts))
+	}
+	return files, nil
+}
+
+// WriteAddon generates the addon for registrations into dir. Functions generated before are removed, so the directory shouldn't hold anything else
+func WriteAddon(dir string, registrations []a3interface.RVExtensionRegistration, opts Options) error {
+	files, err := Generate(registrations, opts)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "functions")); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "functions"), 0755); err != nil {
+		return err
+	}
+	for path, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(path)), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectSetting matches the prefix settings of .hemtt/project.toml
+var projectSetting = regexp.MustCompile(`(?m)^(prefix|mainprefix) = "([^"]*)"`)
+
+// ReadProject returns Options with the prefix and main prefix of the HEMTT project in dir, the directory holding .hemtt
+func ReadProject(dir string) (Options, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ".hemtt", "project.toml"))
+	if err != nil {
+		return Options{}, fmt.Errorf("error reading HEMTT project: %s", err.Error())
+	}
+	var opts Options
+	// only the top level table, before the first [table]
+	top := string(data)
+	if index := strings.Index(top, "\n["); index >= 0 {
+		top = top[:index]
+	}
+	for _, match := range projectSetting.FindAllStringSubmatch(top, -1) {
+		switch match[1] {
+		case "prefix":
+			opts.Prefix = match[2]
+		case "mainprefix":
+			opts.MainPrefix = match[2]
+		}
+	}
+	if opts.Prefix == "" {
+		return Options{}, fmt.Errorf("%s has no prefix", filepath.Join(dir, ".hemtt", "project.toml"))
+	}
+	return opts, nil
+}
+
+// functionPath returns the path of a function's file in the addon
+func functionPath(function string) string {
+	return "functions/fn_" + function + ".sqf"
+}
+
+// wrapperArgs returns the arguments of a schema with the variables they are passed in, which must not clash with each other or the wrapper's own
+func wrapperArgs(schema *a3interface.ArgSchema) []arg {
+	used := map[string]bool{"_callargs": true, "_invalid": true, "_i": true, "_this": true, "_x": true, "_y": true}
+	args := make([]arg, 0, len(schema.Args))
+	for index, spec := range schema.Args {
+		variable := "_" + strings.Trim(identifier.ReplaceAllString(spec.Name, "_"), "_")
+		if variable == "_" || used[strings.ToLower(variable)] {
+			variable = fmt.Sprintf("_arg%d", index+1)
+		}
+		used[strings.ToLower(variable)] = true
+		args = append(args, arg{index: index, spec: spec, variable: variable})
+	}
+	return args
+}
+
+// passed returns an SQF condition that is true if condition is, and the argument was passed if it is optional
+func (a arg) passed(condition string) string {
+	if !a.spec.Optional {
+		return condition
+	}
+	return fmt.Sprintf("!isNil %s && {%s}", sqfString(a.variable), condition)
+}
+
+// sqfString quotes s as an SQF string
+func sqfString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// typeName is the type of an argument in function headers
+func typeName(argType a3interface.ArgType) string {
+	switch argType {
+	case a3interface.ArgHashMap:
+		return "HASHMAP or ARRAY of [key, value] pairs"
+	case a3interface.ArgBool:
+		return "BOOL"
+	case a3interface.ArgString, a3interface.ArgNumber, a3interface.ArgArray:
+		return strings.ToUpper(string(argType))
+	default:
+		return "ANYTHING"
+	}
+}
+
+// expectedTypes are the data types params accepts for an argument
+func expectedTypes(argType a3interface.ArgType) string {
+	switch argType {
+	case a3interface.ArgString:
+		return `[""]`
+	case a3interface.ArgNumber:
+		return "[0]"
+	case a3interface.ArgBool:
+		return "[true]"
+	case a3interface.ArgArray:
+		return "[[]]"
+	case a3interface.ArgHashMap:
+		return "[[], createHashMap]"
+	default:
+		return "[]"
+	}
+}
+
+// exampleValue is a value of an argument type for examples
+func exampleValue(argType a3interface.ArgType) string {
+	switch argType {
+	case a3interface.ArgString:
+		return `"text"`
+	case a3interface.ArgNumber:
+		return "1"
+	case a3interface.ArgBool:
+		return "true"
+	case a3interface.ArgArray:
+		return "[]"
+	case a3interface.ArgHashMap:
+		return `createHashMapFromArray [["key", "value"]]`
+	default:
+		return "nil"
+	}
+}
+
+// typeMessage is the validation message for an argument of the wrong type, matching the extension's own
+func typeMessage(argType a3interface.ArgType) string {
+	switch argType {
+	case a3interface.ArgString, a3interface.ArgNumber, a3interface.ArgBool, a3interface.ArgArray, a3interface.ArgHashMap:
+		article := "a"
+		if argType == a3interface.ArgArray {
+			article = "an"
+		}
+		return fmt.Sprintf("must be %s %s", article, argType)
+	default:
+		return "is required"
+	}
+}
+
+// header writes a function header comment
+func header(b *strings.Builder, lines ...string) {
+	b.WriteString("/*\n")
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString("\t" + line + "\n")
+	}
+	b.WriteString("*/\n")
+}
+
+// wrapperSQF returns the wrapper function of a command
+func wrapperSQF(c command, opts Options) string {
+	tag := opts.Prefix + "_fnc_"
+	lines := []string{fmt.Sprintf("Generated by a3sqf from the registration of %q, do not edit.", c.reg.Command), ""}
+	if c.reg.Description != "" {
+		lines = append(lines, c.reg.Description, "")
+	}
+	if c.reg.RunInBackground {
+		lines = append(lines,
+			fmt.Sprintf("Calls %q, which runs in the background. The value is the command's default response, its results are sent by callback, see %s%s.", c.reg.Command, tag, ExtensionCallbackFunction))
+	} else {
+		lines = append(lines, fmt.Sprintf("Calls %q and returns its response.", c.reg.Command))
+	}
+	if c.reg.TimeBudget > 0 {
+		lines = append(lines, fmt.Sprintf("If it takes longer than %s the value is [\"pending\", jobID], and the response is sent to the %q callback.", c.reg.TimeBudget, a3interface.PendingCallbackFunction))
+	}
+
+	lines = append(lines, "", "Arguments:")
+	switch {
+	case c.reg.ArgSchema == nil:
+		lines = append(lines, "\tthe arguments of the command, unchecked")
+	case len(c.args) == 0 && !c.reg.ArgSchema.Variadic:
+		lines = append(lines, "\tnone")
+	}
+	example := make([]string, 0, len(c.args))
+	for _, a := range c.args {
+		line := fmt.Sprintf("\t%d: %s - %s", a.index, typeName(a.spec.Type), a.spec.Name)
+		if a.spec.Optional {
+			line += ", optional"
+		}
+		unit := " long"
+		if a.spec.Type == a3interface.ArgNumber {
+			unit = ""
+		}
+		switch {
+		case a.spec.Min != nil && a.spec.Max != nil:
+			line += fmt.Sprintf(", %v to %v%s", *a.spec.Min, *a.spec.Max, unit)
+		case a.spec.Min != nil:
+			line += fmt.Sprintf(", at least %v%s", *a.spec.Min, unit)
+		case a.spec.Max != nil:
+			line += fmt.Sprintf(", at most %v%s", *a.spec.Max, unit)
+		}
+		lines = append(lines, line)
+		if !a.spec.Optional {
+			example = append(example, exampleValue(a.spec.Type))
+		}
+	}
+	if c.reg.ArgSchema != nil && c.reg.ArgSchema.Variadic {
+		lines = append(lines, fmt.Sprintf("\t%d...: ANYTHING - further arguments, unchecked", len(c.args)))
+	}
+	lines = append(lines,
+		"",
+		"Returns:",
+		fmt.Sprintf("\tARRAY - [success, value], see %s%s", tag, CallExtensionFunction),
+	)
+	if c.reg.Response != "" && !c.reg.RunInBackground {
+		lines = append(lines, "\tvalue on success: "+c.reg.Response)
+	}
+	lines = append(lines,
+		"",
+		"Example:",
+		fmt.Sprintf("\t[%s] call %s%s;", strings.Join(example, ", "), tag, c.function),
+	)
+
+	var b strings.Builder
+	header(&b, lines...)
+	if c.reg.ArgSchema == nil {
+		b.WriteString("private _callArgs = if (isNil \"_this\") then {[]} else {if (_this isEqualType []) then {+_this} else {[_this]}};\n\n")
+		fmt.Fprintf(&b, "[%s, _callArgs] call %s%s\n", sqfString(c.reg.Command), tag, CallExtensionFunction)
+		return b.String()
+	}
+
+	if len(c.args) > 0 {
+		params := make([]string, 0, len(c.args))
+		for _, a := range c.args {
+			params = append(params, fmt.Sprintf("[%s, nil, %s]", sqfString(a.variable), expectedTypes(a.spec.Type)))
+		}
+		fmt.Fprintf(&b, "params [%s];\n\n", strings.Join(params, ", "))
+
+		// the extension's own validation error
+		b.WriteString("private _invalid = {\n")
+		b.WriteString("\tparams [\"_index\", \"_name\", \"_message\"];\n")
+		fmt.Fprintf(&b, "\t[false, [%s, format [\"invalid arguments for %%1: argument %%2 (%%3) %%4\", %s, _index + 1, _name, _message], [[_index, _name, _message]], false]]\n", sqfString(a3interface.ErrCodeInvalidArgs), sqfString(c.reg.Command))
+		b.WriteString("};\n")
+		for _, a := range c.args {
+			name := sqfString(a.spec.Name)
+			if !a.spec.Optional {
+				fmt.Fprintf(&b, "if (isNil %s) exitWith {\n\t[%d, %s, %s] call _invalid\n};\n", sqfString(a.variable), a.index, name, sqfString(typeMessage(a.spec.Type)))
+			}
+			var length, unit string
+			switch a.spec.Type {
+			case a3interface.ArgNumber:
+				length = a.variable
+			case a3interface.ArgString, a3interface.ArgArray, a3interface.ArgHashMap:
+				length, unit = "count "+a.variable, " in length"
+			default:
+				// the extension doesn't check the range of other types
+				continue
+			}
+			if a.spec.Min != nil {
+				fmt.Fprintf(&b, "if (%s) exitWith {\n\t[%d, %s, format [\"must be at least %v%s, got %%1\", %s]] call _invalid\n};\n", a.passed(fmt.Sprintf("%s < %v", length, *a.spec.Min)), a.index, name, *a.spec.Min, unit, length)
+			}
+			if a.spec.Max != nil {
+				fmt.Fprintf(&b, "if (%s) exitWith {\n\t[%d, %s, format [\"must be at most %v%s, got %%1\", %s]] call _invalid\n};\n", a.passed(fmt.Sprintf("%s > %v", length, *a.spec.Max)), a.index, name, *a.spec.Max, unit, length)
+			}
+		}
+		b.WriteString("\n")
+		for _, a := range c.args {
+			if a.spec.Type == a3interface.ArgHashMap {
+				fmt.Fprintf(&b, "if (%s) then {\n\t%s = %s apply {[_x, _y]};\n};\n", a.passed(a.variable+" isEqualType createHashMap"), a.variable, a.variable)
+			}
+		}
+	}
+
+	variables := make([]string, 0, len(c.args))
+	for _, a := range c.args {
+		variables = append(variables, a.variable)
+	}
+	fmt.Fprintf(&b, "private _callArgs = [%s];\n", strings.Join(variables, ", "))
+	required := 0
+	for _, a := range c.args {
+		if !a.spec.Optional {
+			required = a.index + 1
+		}
+	}
+	trim := required < len(c.args)
+	if c.reg.ArgSchema.Variadic {
+		fmt.Fprintf(&b, "if (_this isEqualType [] && {count _this > %d}) then {\n\t_callArgs append (_this select [%d]);\n}", len(c.args), len(c.args))
+		if trim {
+			b.WriteString(" else {\n")
+			writeTrim(&b, "\t", len(c.args), required)
+			b.WriteString("}")
+		}
+		b.WriteString(";\n")
+	} else if trim {
+		writeTrim(&b, "", len(c.args), required)
+	}
+	fmt.Fprintf(&b, "\n[%s, _callArgs] call %s%s\n", sqfString(c.reg.Command), tag, CallExtensionFunction)
+	return b.String()
+}
+
+// writeTrim writes a loop leaving the optional arguments that weren't passed out of _callArgs
+func writeTrim(b *strings.Builder, indent string, count int, required int) {
+	fmt.Fprintf(b, "%s// leave out the optional arguments that weren't passed\n", indent)
+	fmt.Fprintf(b, "%sfor \"_i\" from %d to %d step -1 do {\n", indent, count-1, required)
+	fmt.Fprintf(b, "%s\tif !(isNil {_callArgs select _i}) exitWith {};\n", indent)
+	fmt.Fprintf(b, "%s\t_callArgs deleteAt _i;\n", indent)
+	fmt.Fprintf(b, "%s};\n", indent)
+}
+
+// callExtensionSQF returns the function every wrapper calls the extension through
+func callExtensionSQF(opts Options) string {
+	var b strings.Builder
+	header(&b,
+		"Generated by a3sqf, do not edit.",
+		"",
+		fmt.Sprintf("Calls a command of the %q extension and unwraps its response.", opts.Extension),
+		"",
+		"Arguments:",
+		"\t0: STRING - command",
+		"\t1: ARRAY - arguments",
+		"",
+		"Returns:",
+		"\tARRAY - [success, value]",
+		"\t\tfor [\"ok\", value], or a response without an envelope: [true, value]",
+		"\t\tfor [\"error\", code, message, details, retryable]: [false, [code, message, details, retryable]]",
+		"\t\tfor [\"pending\", jobID], sent when a call exceeds its time budget: [true, [\"pending\", jobID]]",
+		"\t\tif Arma rejects the call: [false, [\"CALL_EXTENSION\", message, [errorCode], false]]",
+	)
+	fmt.Fprintf(&b, `params [["_command", "", [""]], ["_args", [], [[]]]];
+
+(%s callExtension [_command, _args]) params ["_response", "_returnCode", "_errorCode"];
+// 101 to 201 mean Arma didn't call the extension, 301 only that it took too long
+if (_errorCode > 0 && _errorCode < 300) exitWith {
+	diag_log format ["%s: callExtension %%1 failed with error code %%2", _command, _errorCode];
+	[false, ["CALL_EXTENSION", format ["callExtension failed with error code %%1", _errorCode], [_errorCode], false]]
+};
+
+if !((_response select [0, 1]) isEqualTo "[") exitWith {
+	[true, _response]
+};
+private _parsed = parseSimpleArray _response;
+switch (_parsed param [0, ""]) do {
+	case "ok": {
+		[true, _parsed param [1, ""]]
+	};
+	case "error": {
+		_parsed params ["", ["_code", ""], ["_message", ""], ["_details", []], ["_retryable", false]];
+		diag_log format ["%s: %%1 returned error %%2: %%3 %%4", _command, _code, _message, _details];
+		[false, [_code, _message, _details, _retryable]]
+	};
+	default {
+		[true, _parsed]
+	};
+};
+`, sqfString(opts.Extension), opts.Prefix, opts.Prefix)
+	return b.String()
+}
+
+// extensionCallbackSQF returns the ExtensionCallback dispatcher
+func extensionCallbackSQF(callbacks []callback, opts Options) string {
+	handlers := opts.Prefix + "_callbackHandlers"
+	lines := []string{
+		"Generated by a3sqf, do not edit.",
+		"",
+		fmt.Sprintf("Adds an ExtensionCallback mission event handler for the %q extension at postInit. It calls the code in %s under the callback's function with the callback's data, parsed if it is an array:", opts.Extension, handlers),
+		"",
+		fmt.Sprintf("\t%s set [\"myFunction\", {", handlers),
+		"\t\tparams [\"_first\", \"_second\"];",
+		"\t}];",
+		"",
+		"The extension sends:",
+	}
+	functions := make([]string, 0, len(callbacks))
+	for _, c := range callbacks {
+		lines = append(lines, fmt.Sprintf("\t%s: %s", c.function, c.description))
+		functions = append(functions, sqfString(c.function))
+	}
+	if len(callbacks) == 0 {
+		lines = append(lines, "\tno known callbacks")
+	}
+	lines = append(lines, "", "Other callbacks, and callbacks without a handler, are written to the RPT.")
+
+	var b strings.Builder
+	header(&b, lines...)
+	fmt.Fprintf(&b, `if (isNil %s) then {
+	%s = createHashMap;
+};
+
+addMissionEventHandler ["ExtensionCallback", {
+	params ["_extension", "_function", "_data"];
+	if !(_extension isEqualTo %s) exitWith {};
+
+	if !(_function in [%s]) exitWith {
+		diag_log format ["%s: Unknown function %%1 %%2", _function, _data];
+	};
+	private _args = if ((_data select [0, 1]) isEqualTo "[") then {parseSimpleArray _data} else {_data};
+	private _handler = %s get _function;
+	if (isNil "_handler") exitWith {
+		diag_log format ["%s: No handler for %%1 callback %%2", _function, _args];
+	};
+	_args call _handler;
+}];
+`, sqfString(handlers), handlers, sqfString(opts.Extension), strings.Join(functions, ", "), opts.Prefix, handlers, opts.Prefix)
+	return b.String()
+}
+
+// configCpp returns the config.cpp of the addon, declaring its functions in CfgFunctions
+func configCpp(commands []command, opts Options) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated by a3sqf, do not edit.\n\n")
+	fmt.Fprintf(&b, "class CfgPatches {\n\tclass %s_%s {\n", opts.Prefix, opts.Component)
+	b.WriteString("\t\tunits[] = {};\n\t\tweapons[] = {};\n\t\trequiredVersion = 0.1;\n\t\trequiredAddons[] = {\"A3_Data_F\"};\n\t};\n};\n\n")
+	fmt.Fprintf(&b, "class CfgFunctions {\n\tclass %s {\n\t\tclass %s {\n", opts.Prefix, opts.Component)
+	fmt.Fprintf(&b, "\t\t\tfile = \"%s\\%s\\addons\\%s\\functions\";\n", opts.MainPrefix, opts.Prefix, opts.Component)
+	fmt.Fprintf(&b, "\t\t\tclass %s {};\n", CallExtensionFunction)
+	fmt.Fprintf(&b, "\t\t\tclass %s {postInit = 1;};\n", ExtensionCallbackFunction)
+	for _, c := range commands {
+		fmt.Fprintf(&b, "\t\t\tclass %s {};\n", c.function)
+	}
+	b.WriteString("\t\t};\n\t};\n};\n")
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package a3sqf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indig0fox/a3go/a3interface"
+)
+
+// registrations covers the modes and argument types of the generator
+func registrations() []a3interface.RVExtensionRegistration {
+	return []a3interface.RVExtensionRegistration{
+		*a3interface.NewRegistration("saveMyCall").
+			SetDescription("Saves the caller's context to the database.").
+			SetResponse("[rowID]").
+			SetTimeBudget(50 * time.Millisecond).
+			SetArgSchema(a3interface.NewArgSchema().
+				Arg("data", a3interface.ArgArray).Range(1, 5).
+				OptionalArg("count", a3interface.ArgNumber).Range(0, 10).
+				OptionalArg("args", a3interface.ArgHashMap),
+			),
+		*a3interface.NewRegistration("testAsync").SetRunInBackground(true),
+		*a3interface.NewRegistration("load").
+			SetRunInBackground(true).
+			SetCallbacks(a3interface.CallbackSpec{Function: "loaded", Description: "[id, loadout]"}),
+		*a3interface.NewRegistration("a3go:commands").SetArgSchema(a3interface.NewArgSchema()),
+		*a3interface.NewRegistration("say").SetArgSchema(a3interface.NewArgSchema().
+			Arg("message", a3interface.ArgString).
+			SetVariadic(true),
+		),
+		*a3interface.NewRegistration("player/{id}"),
+	}
+}
+
+const wantSaveMyCall = `/*
+	Generated by a3sqf from the registration of "saveMyCall", do not edit.
+
+	Saves the caller's context to the database.
+
+	Calls "saveMyCall" and returns its response.
+	If it takes longer than 50ms the value is ["pending", jobID], and the response is sent to the "pending" callback.
+
+	Arguments:
+		0: ARRAY - data, 1 to 5 long
+		1: NUMBER - count, optional, 0 to 10
+		2: HASHMAP or ARRAY of [key, value] pairs - args, optional
+
+	Returns:
+		ARRAY - [success, value], see myext_fnc_callExtension
+		value on success: [rowID]
+
+	Example:
+		[[]] call myext_fnc_saveMyCall;
+*/
+params [["_data", nil, [[]]], ["_count", nil, [0]], ["_args", nil, [[], createHashMap]]];
+
+private _invalid = {
+	params ["_index", "_name", "_message"];
+	[false, ["INVALID_ARGS", format ["invalid arguments for %1: argument %2 (%3) %4", "saveMyCall", _index + 1, _name, _message], [[_index, _name, _message]], false]]
+};
+if (isNil "_data") exitWith {
+	[0, "data", "must be an array"] call _invalid
+};
+if (count _data < 1) exitWith {
+	[0, "data", format ["must be at least 1 in length, got %1", count _data]] call _invalid
+};
+if (count _data > 5) exitWith {
+	[0, "data", format ["must be at most 5 in length, got %1", count _data]] call _invalid
+};
+if (!isNil "_count" && {_count < 0}) exitWith {
+	[1, "count", format ["must be at least 0, got %1", _count]] call _invalid
+};
+if (!isNil "_count" && {_count > 10}) exitWith {
+	[1, "count", format ["must be at most 10, got %1", _count]] call _invalid
+};
+
+if (!isNil "_args" && {_args isEqualType createHashMap}) then {
+	_args = _args apply {[_x, _y]};
+};
+private _callArgs = [_data, _count, _args];
+// leave out the optional arguments that weren't passed
+for "_i" from 2 to 1 step -1 do {
+	if !(isNil {_callArgs select _i}) exitWith {};
+	_callArgs deleteAt _i;
+};
+
+["saveMyCall", _callArgs] call myext_fnc_callExtension
+`
+
+func TestGenerate(t *testing.T) {
+	files, err := Generate(registrations(), Options{
+		Extension: "MyExt",
+		Prefix:    "myext",
+		Callbacks: map[string]string{"log": "[level, message, attributes] of warnings"},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if got := string(files["functions/fn_saveMyCall.sqf"]); got != wantSaveMyCall {
+		t.Errorf("Generate() fn_saveMyCall.sqf =\n%s\nwant\n%s", got, wantSaveMyCall)
+	}
+	if _, ok := files["functions/fn_player__id_.sqf"]; ok || len(files) != 9 {
+		t.Errorf("Generate() wrote %d files, want 9 without a wrapper for the pattern command", len(files))
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "$PBOPREFIX$", want: []string{`x\myext\addons\commands`}},
+		{path: "config.cpp", want: []string{
+			"class myext_commands {",
+			"\t\tclass commands {\n\t\t\tfile = \"x\\myext\\addons\\commands\\functions\";\n\t\t\tclass callExtension {};\n\t\t\tclass extensionCallback {postInit = 1;};\n\t\t\tclass a3go_commands {};\n\t\t\tclass load {};\n\t\t\tclass saveMyCall {};\n\t\t\tclass say {};\n\t\t\tclass testAsync {};\n\t\t};",
+		}},
+		{path: "functions/fn_callExtension.sqf", want: []string{`("MyExt" callExtension [_command, _args])`}},
+		{path: "functions/fn_extensionCallback.sqf", want: []string{
+			`if !(_extension isEqualTo "MyExt") exitWith {};`,
+			`if !(_function in ["loaded", "log", "pending", "testAsync"]) exitWith {`,
+			"myext_callbackHandlers get _function",
+		}},
+		{path: "functions/fn_a3go_commands.sqf", want: []string{"\tnone\n", "private _callArgs = [];\n\n[\"a3go:commands\", _callArgs] call myext_fnc_callExtension"}},
+		{path: "functions/fn_testAsync.sqf", want: []string{"runs in the background", "if (_this isEqualType []) then {+_this} else {[_this]}"}},
+		{path: "functions/fn_say.sqf", want: []string{
+			`params [["_message", nil, [""]]];`,
+			`[0, "message", "must be a string"] call _invalid`,
+			"if (_this isEqualType [] && {count _this > 1}) then {\n\t_callArgs append (_this select [1]);\n};",
+		}},
+	}
+	for _, tt := range tests {
+		content, ok := files[tt.path]
+		if !ok {
+			t.Errorf("Generate() didn't generate %s", tt.path)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("Generate() %s =\n%s\nwant it to contain\n%s", tt.path, content, want)
+			}
+		}
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		registrations []a3interface.RVExtensionRegistration
+		opts          Options
+	}{
+		{name: "missing extension", opts: Options{Prefix: "myext"}},
+		{name: "invalid prefix", opts: Options{Extension: "MyExt", Prefix: "my-ext"}},
+		{
+			name:          "same wrapper",
+			registrations: []a3interface.RVExtensionRegistration{*a3interface.NewRegistration("a:b"), *a3interface.NewRegistration("A_b")},
+			opts:          Options{Extension: "MyExt", Prefix: "myext"},
+		},
+		{
+			name:          "generated function",
+			registrations: []a3interface.RVExtensionRegistration{*a3interface.NewRegistration("callExtension")},
+			opts:          Options{Extension: "MyExt", Prefix: "myext"},
+		},
+	}
+	for _, tt := range tests {
+		if _, err := Generate(tt.registrations, tt.opts); err == nil {
+			t.Errorf("%s: Generate() error = nil", tt.name)
+		}
+	}
+}
+
+func TestWriteAddon(t *testing.T) {
+	opts, err := ReadProject(filepath.Join("..", "template"))
+	if err != nil {
+		t.Fatalf("ReadProject() error = %v", err)
+	}
+	if opts.Prefix != "a3go" || opts.MainPrefix != "x" {
+		t.Errorf("ReadProject() = %+v, want prefix a3go and main prefix x", opts)
+	}
+
+	dir := t.TempDir()
+	opts.Extension = "EXTENSION_NAME"
+	if err := WriteAddon(dir, registrations(), opts); err != nil {
+		t.Fatalf("WriteAddon() error = %v", err)
+	}
+	// a command that was removed since
+	if err := WriteAddon(dir, registrations()[1:], opts); err != nil {
+		t.Fatalf("WriteAddon() error = %v", err)
+	}
+	for _, path := range []string{"config.cpp", "$PBOPREFIX$", "functions/fn_testAsync.sqf"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("WriteAddon() didn't write %s: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "functions", "fn_saveMyCall.sqf")); err == nil {
+		t.Errorf("WriteAddon() kept the wrapper of a removed command")
+	}
+}
@@ -0,0 +1,121 @@
+package a3test
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Callback is a callback the extension sent to Arma
+type Callback struct {
+	// Name is the extension name passed to WriteArmaCallback
+	Name string
+	// Function is the function name passed to WriteArmaCallback
+	Function string
+	// Data is the SQF array sent as the callback's data
+	Data string
+	Time time.Time
+}
+
+// Matcher matches the Data of a callback
+type Matcher func(data string) bool
+
+// Any matches any data
+func Any() Matcher {
+	return func(data string) bool { return true }
+}
+
+// Equals matches data that is exactly want
+func Equals(want string) Matcher {
+	return func(data string) bool { return data == want }
+}
+
+// Contains matches data that contains substr
+func Contains(substr string) Matcher {
+	return func(data string) bool { return strings.Contains(data, substr) }
+}
+
+// MatchesRegexp matches data that the regular expression expr matches. It panics if expr is invalid
+func MatchesRegexp(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return re.MatchString
+}
+
+// callbackRecorder records the callbacks a Host receives
+type callbackRecorder struct {
+	mu        sync.Mutex
+	callbacks []Callback
+	// matched marks the callbacks already returned by ExpectCallback
+	matched []bool
+	// result is returned to the extension by the fake callback function
+	result int
+	// changed is closed and replaced whenever a callback is recorded
+	changed chan struct{}
+}
+
+// callback is the fake callback function set on the Extension, standing in for Arma's
+func (r *callbackRecorder) callback(name string, function string, data string) int {
+	return r.record(Callback{
+		Name:     name,
+		Function: function,
+		Data:     data,
+		Time:     time.Now(),
+	})
+}
+
+func (r *callbackRecorder) record(callback Callback) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks = append(r.callbacks, callback)
+	r.matched = append(r.matched, false)
+	close(r.changed)
+	r.changed = make(chan struct{})
+	return r.result
+}
+
+// take marks the first unmatched callback to function that matches as matched and returns it. If there is none, it returns a channel that is closed when the next callback is recorded
+func (r *callbackRecorder) take(function string, matcher Matcher) (Callback, bool, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for index, callback := range r.callbacks {
+		if !r.matched[index] && callback.Function == function && matcher(callback.Data) {
+			r.matched[index] = true
+			return callback, true, nil
+		}
+	}
+	return Callback{}, false, r.changed
+}
+
+// Callbacks returns every callback received since the Host was created
+func (h *Host) Callbacks() []Callback {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+	return append([]Callback(nil), h.recorder.callbacks...)
+}
+
+// SetCallbackResult sets the value the fake callback function returns to the extension. Arma returns a negative value when its callback queue is full
+func (h *Host) SetCallbackResult(result int) {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+	h.recorder.result = result
+}
+
+// ExpectCallback waits up to timeout for a callback to function whose data matches, and returns it. Each callback is only returned once, so expecting the same callback twice waits for a second one. If none arrives in time, the test fails. It must be called from the goroutine running the test
+func (h *Host) ExpectCallback(function string, matcher Matcher, timeout time.Duration) Callback {
+	h.t.Helper()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		callback, ok, changed := h.recorder.take(function, matcher)
+		if ok {
+			return callback
+		}
+		select {
+		case <-changed:
+		case <-deadline.C:
+			h.t.Fatalf("no matching callback to %s within %s, received %v", function, timeout, h.Callbacks())
+			return Callback{}
+		}
+	}
+}
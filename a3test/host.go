@@ -0,0 +1,91 @@
+// Package a3test is a fake Arma host for testing extensions built with a3interface. It calls an Extension through the same entry points the functions exported to Arma use, with a configurable output buffer, and records every callback sent with WriteArmaCallback
+package a3test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/indig0fox/a3go/a3interface"
+)
+
+// DefaultOutputSize is the size of the output buffer used when Host.OutputSize is 0. Set OutputSize to match the game version under test
+const DefaultOutputSize = 10240
+
+// versionOutputSize is the size of the output buffer Arma passes to RVExtensionVersion
+const versionOutputSize = 32
+
+// Host calls an Extension the way Arma does. Hosts of different Extensions share nothing, so tests using them can run in parallel
+type Host struct {
+	// OutputSize is the size of the output buffer passed to RVExtension and RVExtensionArgs, including the null terminator
+	OutputSize int
+	// Context is sent with RVExtensionContext before every call
+	Context a3interface.ArmaExtensionContext
+
+	t         testing.TB
+	extension *a3interface.Extension
+	recorder  *callbackRecorder
+}
+
+// NewHost returns a Host that calls ext and reports failed expectations to t. Pass a3interface.Default() to test the registrations made with Register. It sets a fake callback function on ext that records every callback, which is removed again when the test ends
+func NewHost(t testing.TB, ext *a3interface.Extension) *Host {
+	h := &Host{
+		OutputSize: DefaultOutputSize,
+		Context: a3interface.ArmaExtensionContext{
+			SteamID:             "0",
+			FileSource:          "",
+			MissionNameSource:   "a3test",
+			ServerName:          "a3test",
+			RemoteExecutedOwner: "0",
+		},
+		t:         t,
+		extension: ext,
+		recorder:  &callbackRecorder{changed: make(chan struct{})},
+	}
+	ext.SetCallback(h.recorder.callback)
+	t.Cleanup(func() {
+		ext.SetCallback(nil)
+	})
+	return h
+}
+
+// Extension returns the Extension the host calls
+func (h *Host) Extension() *a3interface.Extension {
+	return h.extension
+}
+
+// Version returns the version, as RVExtensionVersion does when Arma loads the extension
+func (h *Host) Version() string {
+	return truncate(h.extension.Version(), versionOutputSize)
+}
+
+// Call calls the extension with input, as in "extension" callExtension "command|data", and returns what the extension wrote to the output buffer
+func (h *Host) Call(input string) string {
+	h.extension.SetContext(h.Context)
+	return truncate(h.extension.Call(input, h.outputSize()), h.outputSize())
+}
+
+// CallArgs calls the extension as in "extension" callExtension ["command", [args]], and returns what the extension wrote to the output buffer. Args are passed exactly as given, so strings must be quoted the way Arma quotes them, see Quote
+func (h *Host) CallArgs(command string, args ...string) string {
+	h.extension.SetContext(h.Context)
+	return truncate(h.extension.CallArgs(command, args, h.outputSize()), h.outputSize())
+}
+
+// Quote returns s as Arma passes a string argument to RVExtensionArgs, i.e. `"say ""hi"""`
+func Quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func (h *Host) outputSize() int {
+	if h.OutputSize <= 0 {
+		return DefaultOutputSize
+	}
+	return h.OutputSize
+}
+
+// truncate cuts s to fit an output buffer of size bytes, leaving room for the null terminator
+func truncate(s string, size int) string {
+	if len(s) > size-1 {
+		return s[:size-1]
+	}
+	return s
+}
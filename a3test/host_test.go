@@ -0,0 +1,106 @@
+package a3test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indig0fox/a3go/a3interface"
+)
+
+func TestHost(t *testing.T) {
+	ext := a3interface.NewExtension()
+	ext.SetVersion("1.2.3")
+	registerHostCommands(t, ext)
+
+	host := NewHost(t, ext)
+	host.Context.MissionNameSource = "co10_Escape"
+
+	tests := []struct {
+		name       string
+		outputSize int
+		call       func() string
+		want       string
+	}{
+		{name: "version", call: host.Version, want: "1.2.3"},
+		{name: "string form", call: func() string { return host.Call("hostEcho|a|b") }, want: "co10_Escape:a,b"},
+		{name: "args form", call: func() string { return host.CallArgs("hostEcho", Quote(`say "hi"`), "1") }, want: `co10_Escape:say "hi",1`},
+		{name: "truncated to output size", outputSize: 6, call: func() string { return host.Call("hostEcho|a") }, want: "co10_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.OutputSize = tt.outputSize
+			if got := tt.call(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	host.OutputSize = 0
+	if got := host.CallArgs("hostAsync", Quote("saved")); got != `["Command hostAsync called"]` {
+		t.Errorf("CallArgs() = %v, want default response", got)
+	}
+	callback := host.ExpectCallback("hostAsync", Equals(`["saved"]`), time.Second)
+	if callback.Name != "hostExtension" {
+		t.Errorf("callback.Name = %v, want hostExtension", callback.Name)
+	}
+
+	// a full callback queue is reported to the extension
+	host.SetCallbackResult(-1)
+	dropped := ext.Subscribe(1, a3interface.EventCallbackDropped)
+	defer dropped.Unsubscribe()
+	host.CallArgs("hostAsync", Quote("lost"))
+	host.ExpectCallback("hostAsync", Contains("lost"), time.Second)
+	select {
+	case <-dropped.C:
+	case <-time.After(time.Second):
+		t.Error("no callback dropped event for a full queue")
+	}
+}
+
+func TestHost_parallel(t *testing.T) {
+	for _, mission := range []string{"co10_Escape", "tvt20_Hill"} {
+		mission := mission
+		t.Run(mission, func(t *testing.T) {
+			t.Parallel()
+			ext := a3interface.NewExtension()
+			registerHostCommands(t, ext)
+			host := NewHost(t, ext)
+			host.Context.MissionNameSource = mission
+
+			for i := 0; i < 10; i++ {
+				if got, want := host.Call("hostEcho|a"), mission+":a"; got != want {
+					t.Fatalf("Call() = %v, want %v", got, want)
+				}
+				host.CallArgs("hostAsync", Quote(mission))
+			}
+			for i := 0; i < 10; i++ {
+				host.ExpectCallback("hostAsync", Contains(mission), time.Second)
+			}
+			if got := len(host.Callbacks()); got != 10 {
+				t.Errorf("len(Callbacks()) = %v, want only the 10 of this host", got)
+			}
+		})
+	}
+}
+
+// registerHostCommands registers the commands the host tests call
+func registerHostCommands(t *testing.T, ext *a3interface.Extension) {
+	t.Helper()
+	err := ext.Register(a3interface.NewRegistration("hostEcho").
+		SetHandler(a3interface.HandlerFunc(func(req *a3interface.Request) error {
+			_, err := req.Writer.WriteString(req.ArmaContext.MissionNameSource + ":" + strings.Join(req.Args, ","))
+			return err
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	err = ext.Register(a3interface.NewRegistration("hostAsync").
+		SetRunInBackground(true).
+		SetHandler(a3interface.HandlerFunc(func(req *a3interface.Request) error {
+			return req.Extension().WriteArmaCallback("hostExtension", "hostAsync", req.Args...)
+		})))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+}
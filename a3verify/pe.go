@@ -0,0 +1,90 @@
+package a3verify
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+)
+
+// peExportDirectory is the IMAGE_EXPORT_DIRECTORY of a PE file
+type peExportDirectory struct {
+	Characteristics       uint32
+	TimeDateStamp         uint32
+	MajorVersion          uint16
+	MinorVersion          uint16
+	Name                  uint32
+	Base                  uint32
+	NumberOfFunctions     uint32
+	NumberOfNames         uint32
+	AddressOfFunctions    uint32
+	AddressOfNames        uint32
+	AddressOfNameOrdinals uint32
+}
+
+// peExports returns the names in the export table of a PE file. debug/pe does not read the export table, so it is read from the section holding it
+func peExports(file *pe.File) ([]string, error) {
+	var directory pe.DataDirectory
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if header.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_EXPORT {
+			directory = header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT]
+		}
+	case *pe.OptionalHeader64:
+		if header.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_EXPORT {
+			directory = header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT]
+		}
+	default:
+		return nil, fmt.Errorf("missing optional header")
+	}
+	if directory.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var exports peExportDirectory
+	if err := binary.Read(bytes.NewReader(peRead(file, directory.VirtualAddress, uint32(binary.Size(exports)))), binary.LittleEndian, &exports); err != nil {
+		return nil, fmt.Errorf("invalid export directory")
+	}
+	addresses := peRead(file, exports.AddressOfNames, exports.NumberOfNames*4)
+	if uint32(len(addresses)) != exports.NumberOfNames*4 {
+		return nil, fmt.Errorf("invalid export name table")
+	}
+	names := make([]string, 0, exports.NumberOfNames)
+	for i := uint32(0); i < exports.NumberOfNames; i++ {
+		address := binary.LittleEndian.Uint32(addresses[i*4:])
+		names = append(names, peString(file, address))
+	}
+	return names, nil
+}
+
+// peRead returns up to size bytes at a relative virtual address, fewer if they are outside the section holding it
+func peRead(file *pe.File, rva uint32, size uint32) []byte {
+	for _, section := range file.Sections {
+		if rva < section.VirtualAddress || rva >= section.VirtualAddress+section.VirtualSize {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			return nil
+		}
+		offset := rva - section.VirtualAddress
+		if offset >= uint32(len(data)) {
+			return nil
+		}
+		end := offset + size
+		if end > uint32(len(data)) || end < offset {
+			end = uint32(len(data))
+		}
+		return data[offset:end]
+	}
+	return nil
+}
+
+// peString returns the null terminated string at a relative virtual address
+func peString(file *pe.File, rva uint32) string {
+	data := peRead(file, rva, 1024)
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return string(data)
+}
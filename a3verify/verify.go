@@ -0,0 +1,230 @@
+// Package a3verify inspects built extensions before they are shipped. Arma only reports "extension not found" for an extension it can't use, so Verify checks the things that cause it: the RVExtension exports, the architecture, the "_x64" file name suffix and, for 32-bit Windows, the stdcall decoration of the exports
+package a3verify
+
+import (
+	"debug/elf"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Export is a function the engine looks up in an extension
+type Export struct {
+	Name string
+	// Required exports must be present for the engine to load the extension
+	Required bool
+	// StackSize is the size of the arguments in bytes, which 32-bit Windows stdcall decoration includes in the name
+	StackSize int
+}
+
+// Exports are the functions the engine looks up, in the order it calls them
+var Exports = []Export{
+	{Name: "RVExtensionRegisterCallback", StackSize: 4},
+	{Name: "RVExtensionVersion", Required: true, StackSize: 8},
+	{Name: "RVExtensionContext", StackSize: 8},
+	{Name: "RVExtension", Required: true, StackSize: 12},
+	{Name: "RVExtensionArgs", Required: true, StackSize: 20},
+}
+
+// Symbol returns the name the engine looks the export up by on a format and architecture, "_RVExtension@12" for 32-bit Windows and the plain name otherwise
+func (e Export) Symbol(format string, arch string) string {
+	if format == FormatPE && arch == ArchX86 {
+		return fmt.Sprintf("_%s@%d", e.Name, e.StackSize)
+	}
+	return e.Name
+}
+
+// Formats of the artifacts Verify reads
+const (
+	FormatELF = "elf"
+	FormatPE  = "pe"
+)
+
+// Architectures of the artifacts Verify reads. Other architectures are reported by their machine name and can't be loaded by Arma
+const (
+	ArchX86 = "x86"
+	ArchX64 = "x64"
+)
+
+// ExportStatus reports whether an export was found
+type ExportStatus struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Required bool   `json:"required"`
+	Found    bool   `json:"found"`
+}
+
+// Report is the result of verifying an artifact. It is encoded as JSON by WriteJSON
+type Report struct {
+	Path    string         `json:"path"`
+	Format  string         `json:"format"`
+	Arch    string         `json:"arch"`
+	Exports []ExportStatus `json:"exports"`
+	// Problems stop Arma from loading the extension
+	Problems []string `json:"problems"`
+	// Warnings are worth fixing but don't stop Arma from loading the extension
+	Warnings []string `json:"warnings"`
+	OK       bool     `json:"ok"`
+}
+
+// artifact is what Verify reads from a file
+type artifact struct {
+	format  string
+	arch    string
+	symbols []string
+	// library is false for executables
+	library bool
+}
+
+// Verify reads the artifact at path and checks that Arma can load it
+func Verify(path string) (*Report, error) {
+	a, err := readArtifact(path)
+	if err != nil {
+		return nil, err
+	}
+	return check(path, a), nil
+}
+
+// readArtifact reads the format, architecture and exported symbols of an ELF or PE file
+func readArtifact(path string) (artifact, error) {
+	if file, err := elf.Open(path); err == nil {
+		defer file.Close()
+		symbols, err := elfExports(file)
+		if err != nil {
+			return artifact{}, fmt.Errorf("error reading exports of %s: %s", path, err.Error())
+		}
+		return artifact{format: FormatELF, arch: elfArch(file.Machine), symbols: symbols, library: file.Type == elf.ET_DYN}, nil
+	}
+	if file, err := pe.Open(path); err == nil {
+		defer file.Close()
+		symbols, err := peExports(file)
+		if err != nil {
+			return artifact{}, fmt.Errorf("error reading exports of %s: %s", path, err.Error())
+		}
+		return artifact{format: FormatPE, arch: peArch(file.Machine), symbols: symbols, library: file.Characteristics&pe.IMAGE_FILE_DLL != 0}, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return artifact{}, fmt.Errorf("error opening %s: %s", path, err.Error())
+	}
+	return artifact{}, fmt.Errorf("%s is not an ELF shared object or a PE DLL", path)
+}
+
+// check verifies the exports, architecture and file name of an artifact
+func check(path string, a artifact) *Report {
+	format, arch := a.format, a.arch
+	report := &Report{Path: path, Format: format, Arch: arch, Problems: []string{}, Warnings: []string{}}
+	if !a.library {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is an executable, build it with -buildmode=c-shared", filepath.Base(path)))
+	}
+	exported := make(map[string]bool, len(a.symbols))
+	for _, symbol := range a.symbols {
+		exported[symbol] = true
+	}
+
+	for _, export := range Exports {
+		symbol := export.Symbol(format, arch)
+		status := ExportStatus{Name: export.Name, Symbol: symbol, Required: export.Required, Found: exported[symbol]}
+		report.Exports = append(report.Exports, status)
+		if status.Found {
+			continue
+		}
+		message := fmt.Sprintf("missing export %s", symbol)
+		if symbol != export.Name && exported[export.Name] {
+			// the usual mistake: cgo exports undecorated cdecl names on windows/386
+			message = fmt.Sprintf("export %s is not decorated, 32-bit Arma looks for %s", export.Name, symbol)
+		}
+		if export.Required {
+			report.Problems = append(report.Problems, message)
+		} else {
+			report.Warnings = append(report.Warnings, message)
+		}
+	}
+
+	name := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(name))
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	switch {
+	case format == FormatELF && ext != ".so":
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is an ELF file, its name must end with .so", name))
+	case format == FormatPE && ext != ".dll":
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is a PE file, its name must end with .dll", name))
+	}
+	switch arch {
+	case ArchX64:
+		if !strings.HasSuffix(base, "_x64") {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s is 64-bit, its name must end with _x64%s", name, ext))
+		}
+	case ArchX86:
+		if strings.HasSuffix(base, "_x64") {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s is 32-bit, its name must not end with _x64%s", name, ext))
+		}
+	default:
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is built for %s, Arma only loads x86 and x64 extensions", name, arch))
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report
+}
+
+// Err returns an error listing the problems of the report, or nil if it is OK
+func (r *Report) Err() error {
+	if r.OK {
+		return nil
+	}
+	return fmt.Errorf("%s can't be loaded by Arma: %s", r.Path, strings.Join(r.Problems, "; "))
+}
+
+// WriteJSON writes reports as an indented JSON array
+func WriteJSON(w io.Writer, reports []*Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
+
+func elfArch(machine elf.Machine) string {
+	switch machine {
+	case elf.EM_X86_64:
+		return ArchX64
+	case elf.EM_386:
+		return ArchX86
+	default:
+		return strings.TrimPrefix(machine.String(), "EM_")
+	}
+}
+
+func peArch(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return ArchX64
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return ArchX86
+	default:
+		return fmt.Sprintf("machine 0x%x", machine)
+	}
+}
+
+// elfExports returns the defined global functions of the dynamic symbol table
+func elfExports(file *elf.File) ([]string, error) {
+	symbols, err := file.DynamicSymbols()
+	if err != nil {
+		if err == elf.ErrNoSymbols {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, symbol := range symbols {
+		if symbol.Section == elf.SHN_UNDEF || elf.ST_TYPE(symbol.Info) != elf.STT_FUNC {
+			continue
+		}
+		if bind := elf.ST_BIND(symbol.Info); bind != elf.STB_GLOBAL && bind != elf.STB_WEAK {
+			continue
+		}
+		names = append(names, symbol.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,207 @@
+package a3verify
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var (
+	plainExports     = []string{"RVExtensionRegisterCallback", "RVExtensionVersion", "RVExtensionContext", "RVExtension", "RVExtensionArgs"}
+	decoratedExports = []string{"_RVExtensionRegisterCallback@4", "_RVExtensionVersion@8", "_RVExtensionContext@8", "_RVExtension@12", "_RVExtensionArgs@20"}
+)
+
+func Test_check(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		artifact     artifact
+		wantProblems []string
+		wantWarnings []string
+	}{
+		{
+			name:     "linux x64",
+			path:     "dist/ext_x64.so",
+			artifact: artifact{format: FormatELF, arch: ArchX64, symbols: plainExports, library: true},
+		},
+		{
+			name:     "windows x86 decorated",
+			path:     "dist/ext.dll",
+			artifact: artifact{format: FormatPE, arch: ArchX86, symbols: decoratedExports, library: true},
+		},
+		{
+			name:     "windows x86 undecorated",
+			path:     "dist/ext.dll",
+			artifact: artifact{format: FormatPE, arch: ArchX86, symbols: plainExports, library: true},
+			wantProblems: []string{
+				"export RVExtensionVersion is not decorated, 32-bit Arma looks for _RVExtensionVersion@8",
+				"export RVExtension is not decorated, 32-bit Arma looks for _RVExtension@12",
+				"export RVExtensionArgs is not decorated, 32-bit Arma looks for _RVExtensionArgs@20",
+			},
+			wantWarnings: []string{
+				"export RVExtensionRegisterCallback is not decorated, 32-bit Arma looks for _RVExtensionRegisterCallback@4",
+				"export RVExtensionContext is not decorated, 32-bit Arma looks for _RVExtensionContext@8",
+			},
+		},
+		{
+			name:         "missing optional exports",
+			path:         "ext_x64.dll",
+			artifact:     artifact{format: FormatPE, arch: ArchX64, symbols: []string{"RVExtensionVersion", "RVExtension", "RVExtensionArgs"}, library: true},
+			wantWarnings: []string{"missing export RVExtensionRegisterCallback", "missing export RVExtensionContext"},
+		},
+		{
+			name:         "x64 without suffix",
+			path:         "ext.so",
+			artifact:     artifact{format: FormatELF, arch: ArchX64, symbols: plainExports, library: true},
+			wantProblems: []string{"ext.so is 64-bit, its name must end with _x64.so"},
+		},
+		{
+			name:         "x86 with suffix",
+			path:         "ext_x64.dll",
+			artifact:     artifact{format: FormatPE, arch: ArchX86, symbols: decoratedExports, library: true},
+			wantProblems: []string{"ext_x64.dll is 32-bit, its name must not end with _x64.dll"},
+		},
+		{
+			name:         "wrong extension",
+			path:         "ext_x64.dll",
+			artifact:     artifact{format: FormatELF, arch: ArchX64, symbols: plainExports, library: true},
+			wantProblems: []string{"ext_x64.dll is an ELF file, its name must end with .so"},
+		},
+		{
+			name:         "arm64",
+			path:         "ext_x64.so",
+			artifact:     artifact{format: FormatELF, arch: "AARCH64", symbols: plainExports, library: true},
+			wantProblems: []string{"ext_x64.so is built for AARCH64, Arma only loads x86 and x64 extensions"},
+		},
+		{
+			name:         "executable",
+			path:         "ext_x64.so",
+			artifact:     artifact{format: FormatELF, arch: ArchX64, library: false},
+			wantProblems: []string{"ext_x64.so is an executable, build it with -buildmode=c-shared", "missing export RVExtensionVersion", "missing export RVExtension", "missing export RVExtensionArgs"},
+			wantWarnings: []string{"missing export RVExtensionRegisterCallback", "missing export RVExtensionContext"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := check(tt.path, tt.artifact)
+			if tt.wantProblems == nil {
+				tt.wantProblems = []string{}
+			}
+			if tt.wantWarnings == nil {
+				tt.wantWarnings = []string{}
+			}
+			if !reflect.DeepEqual(report.Problems, tt.wantProblems) {
+				t.Errorf("check() problems = %q, want %q", report.Problems, tt.wantProblems)
+			}
+			if !reflect.DeepEqual(report.Warnings, tt.wantWarnings) {
+				t.Errorf("check() warnings = %q, want %q", report.Warnings, tt.wantWarnings)
+			}
+			if report.OK != (len(tt.wantProblems) == 0) || (report.Err() == nil) != report.OK {
+				t.Errorf("check() ok = %v, err = %v", report.OK, report.Err())
+			}
+		})
+	}
+}
+
+// writePE writes a minimal PE DLL with an export table holding names
+func writePE(t *testing.T, path string, machine uint16, names []string) {
+	const sectionRVA, sectionOffset = 0x1000, 0x400
+
+	// export directory, then the name pointer table, then the names
+	var section bytes.Buffer
+	directorySize := uint32(binary.Size(peExportDirectory{}))
+	nameRVA := sectionRVA + directorySize + uint32(len(names))*4
+	pointers := make([]uint32, len(names))
+	var table bytes.Buffer
+	for i, name := range names {
+		pointers[i] = nameRVA + uint32(table.Len())
+		table.WriteString(name)
+		table.WriteByte(0)
+	}
+	binary.Write(&section, binary.LittleEndian, peExportDirectory{
+		NumberOfNames:  uint32(len(names)),
+		AddressOfNames: sectionRVA + directorySize,
+	})
+	binary.Write(&section, binary.LittleEndian, pointers)
+	section.Write(table.Bytes())
+
+	directory := pe.DataDirectory{VirtualAddress: sectionRVA, Size: uint32(section.Len())}
+	var optional interface{}
+	if machine == pe.IMAGE_FILE_MACHINE_AMD64 {
+		header := pe.OptionalHeader64{Magic: 0x20b, NumberOfRvaAndSizes: 16}
+		header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT] = directory
+		optional = header
+	} else {
+		header := pe.OptionalHeader32{Magic: 0x10b, NumberOfRvaAndSizes: 16}
+		header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT] = directory
+		optional = header
+	}
+
+	var file bytes.Buffer
+	dos := make([]byte, 64)
+	copy(dos, "MZ")
+	binary.LittleEndian.PutUint32(dos[0x3c:], 64)
+	file.Write(dos)
+	file.WriteString("PE\x00\x00")
+	binary.Write(&file, binary.LittleEndian, pe.FileHeader{
+		Machine:              machine,
+		NumberOfSections:     1,
+		SizeOfOptionalHeader: uint16(binary.Size(optional)),
+		Characteristics:      pe.IMAGE_FILE_DLL | pe.IMAGE_FILE_EXECUTABLE_IMAGE,
+	})
+	binary.Write(&file, binary.LittleEndian, optional)
+	binary.Write(&file, binary.LittleEndian, pe.SectionHeader32{
+		Name:             [8]uint8{'.', 'e', 'd', 'a', 't', 'a'},
+		VirtualSize:      uint32(section.Len()),
+		VirtualAddress:   sectionRVA,
+		SizeOfRawData:    uint32(section.Len()),
+		PointerToRawData: sectionOffset,
+	})
+	file.Write(make([]byte, sectionOffset-file.Len()))
+	file.Write(section.Bytes())
+
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		file     string
+		machine  uint16
+		exports  []string
+		wantArch string
+		wantOK   bool
+	}{
+		{name: "x64", file: "ext_x64.dll", machine: pe.IMAGE_FILE_MACHINE_AMD64, exports: plainExports, wantArch: ArchX64, wantOK: true},
+		{name: "x86", file: "ext.dll", machine: pe.IMAGE_FILE_MACHINE_I386, exports: decoratedExports, wantArch: ArchX86, wantOK: true},
+		{name: "x86 undecorated", file: "ext.dll", machine: pe.IMAGE_FILE_MACHINE_I386, exports: plainExports, wantArch: ArchX86, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name, tt.file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			writePE(t, path, tt.machine, tt.exports)
+
+			report, err := Verify(path)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if report.Format != FormatPE || report.Arch != tt.wantArch || report.OK != tt.wantOK {
+				t.Errorf("Verify() = %s %s ok %v %q, want pe %s ok %v", report.Format, report.Arch, report.OK, report.Problems, tt.wantArch, tt.wantOK)
+			}
+		})
+	}
+
+	if _, err := Verify(filepath.Join(dir, "missing.dll")); err == nil {
+		t.Errorf("Verify() error = nil, want an error for a missing file")
+	}
+}
@@ -0,0 +1,64 @@
+// Command a3doc renders the manifest of an extension to Markdown, for mission makers who don't read Go. The template writes the manifest with its "manifest" subcommand, built with the a3gen tag:
+//
+//	go run -tags a3gen . manifest -o manifest.json
+//	a3doc -o COMMANDS.md manifest.json
+//
+// The manifest is read from standard input if the file is "-" or left out
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/indig0fox/a3go/a3doc"
+	"github.com/indig0fox/a3go/a3interface"
+)
+
+func main() {
+	output := flag.String("o", "", "write the Markdown to `file` instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: a3doc [-o file] [manifest.json]\n\nflags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var manifest a3interface.ExtensionManifest
+	var err error
+	if name := flag.Arg(0); name != "" && name != "-" {
+		manifest, err = a3doc.ReadManifestFile(name)
+	} else {
+		manifest, err = a3doc.ReadManifest(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "a3doc: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		err = a3doc.WriteMarkdown(os.Stdout, manifest)
+	} else {
+		err = writeFile(*output, manifest)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "a3doc: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// writeFile writes the Markdown of manifest to a file
+func writeFile(name string, manifest a3interface.ExtensionManifest) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := a3doc.WriteMarkdown(file, manifest); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
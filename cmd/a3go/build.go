@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/indig0fox/a3go/a3verify"
+)
+
+// target is a platform Arma loads extensions on
+type target struct {
+	goos   string
+	goarch string
+}
+
+// targets are the platforms that can be built. windows/386 is not supported: cgo exports undecorated cdecl functions there, while 32-bit Arma looks up and calls stdcall exports like _RVExtension@12, so the DLL can't be loaded
+var targets = []target{
+	{goos: "linux", goarch: "amd64"},
+	{goos: "linux", goarch: "386"},
+	{goos: "windows", goarch: "amd64"},
+}
+
+func (t target) String() string {
+	return t.goos + "/" + t.goarch
+}
+
+// fileName returns the name Arma loads the extension by on the target, with the "_x64" suffix for 64-bit builds
+func (t target) fileName(name string) string {
+	if t.goarch == "amd64" {
+		name += "_x64"
+	}
+	if t.goos == "windows" {
+		return name + ".dll"
+	}
+	return name + ".so"
+}
+
+// cc returns the C compiler for the target, set by A3GO_CC_<GOOS>_<GOARCH> or else gcc-multilib and mingw-w64 as installed by build/Dockerfile.build. It is empty for the host platform, which uses the compiler Go would use
+func (t target) cc() string {
+	if cc := os.Getenv("A3GO_CC_" + strings.ToUpper(t.goos) + "_" + strings.ToUpper(t.goarch)); cc != "" {
+		return cc
+	}
+	switch {
+	case t.goos == runtime.GOOS && t.goarch == runtime.GOARCH:
+		return ""
+	case t.goos == "windows" && t.goarch == "amd64":
+		return "x86_64-w64-mingw32-gcc"
+	default:
+		// cgo passes -m32 for 386
+		return "gcc"
+	}
+}
+
+// buildOptions configures a build
+type buildOptions struct {
+	// name of the built files, without the "_x64" suffix and extension
+	name string
+	// dir is the directory of the extension's main package
+	dir string
+	// dist is the directory the built files are written to
+	dist string
+	// version is passed to SetVersion through the template's version variable
+	version string
+	targets []target
+}
+
+func runBuild(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	name := flags.String("name", "", "`name` of the built files, the name of the package directory by default")
+	dist := flags.String("dist", "", "output `directory`, the dist directory next to the package directory by default")
+	version := flags.String("version", "", "`version` to set, read from the HEMTT project's script_version.hpp by default. The git commit is appended")
+	targetList := flags.String("targets", targetNames(targets), "comma separated GOOS/GOARCH `list` to build")
+	verify := flags.Bool("verify", true, "check the built files with a3verify")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: a3go build [flags] [package directory]\n\nThe package directory defaults to the current directory if it holds a go.mod, and to the template of the a3go checkout otherwise.\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	opts := buildOptions{name: *name, dist: *dist}
+	var err error
+	if opts.targets, err = parseTargets(*targetList); err != nil {
+		fmt.Fprintf(os.Stderr, "a3go: -targets: %s\n", err.Error())
+		return 2
+	}
+	if opts.dir, err = findPackage(flags.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "a3go: %s\n", err.Error())
+		return 1
+	}
+	if opts.name == "" {
+		opts.name = filepath.Base(opts.dir)
+	}
+	if opts.dist == "" {
+		opts.dist = filepath.Join(filepath.Dir(opts.dir), "dist")
+	}
+	opts.version = buildVersion(*version, filepath.Dir(opts.dir), opts.dir)
+
+	fmt.Printf("building %s %s\n", opts.name, opts.version)
+	ok := true
+	for _, t := range opts.targets {
+		path, err := build(opts, t)
+		if err != nil {
+			ok = false
+			fmt.Printf("FAIL %s\n  error: %s\n", t, err.Error())
+			continue
+		}
+		if !*verify {
+			fmt.Printf("ok   %s %s\n", t, path)
+			continue
+		}
+		report, err := a3verify.Verify(path)
+		if err != nil {
+			report = &a3verify.Report{Path: path, Problems: []string{err.Error()}}
+		}
+		ok = ok && report.OK
+		status := "ok  "
+		if !report.OK {
+			status = "FAIL"
+		}
+		fmt.Printf("%s %s %s\n", status, t, path)
+		for _, problem := range report.Problems {
+			fmt.Printf("  error: %s\n", problem)
+		}
+		for _, warning := range report.Warnings {
+			fmt.Printf("  warning: %s\n", warning)
+		}
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+// targetNames formats targets for -targets
+func targetNames(targets []target) string {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, t.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// parseTargets parses a comma separated list of GOOS/GOARCH pairs Arma loads extensions on
+func parseTargets(list string) ([]target, error) {
+	var parsed []target
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, t := range targets {
+			if t.String() == name {
+				parsed, found = append(parsed, t), true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown target %q, choose from %s", name, targetNames(targets))
+		}
+	}
+	return parsed, nil
+}
+
+// findPackage returns the absolute directory of the extension to build: dir if set, the current directory if it holds a module other than a3go, or the template of the a3go checkout
+func findPackage(dir string) (string, error) {
+	if dir == "" {
+		if data, err := os.ReadFile("go.mod"); err == nil && modulePath(string(data)) != a3goModule {
+			dir = "."
+		} else {
+			template, err := findTemplate()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(template, placeholder)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		return "", fmt.Errorf("%s is not an extension module: %s", dir, err.Error())
+	}
+	return filepath.Abs(dir)
+}
+
+// build builds the extension for a target and returns the path of the built file
+func build(opts buildOptions, t target) (string, error) {
+	if err := os.MkdirAll(opts.dist, 0755); err != nil {
+		return "", err
+	}
+	path, err := filepath.Abs(filepath.Join(opts.dist, t.fileName(opts.name)))
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("go", "build",
+		"-buildmode=c-shared",
+		"-trimpath",
+		"-ldflags", fmt.Sprintf("-s -w -X 'main.version=%s'", opts.version),
+		"-o", path,
+		".",
+	)
+	cmd.Dir = opts.dir
+	cmd.Env = append(os.Environ(), "GOOS="+t.goos, "GOARCH="+t.goarch, "CGO_ENABLED=1")
+	if cc := t.cc(); cc != "" {
+		if _, err := exec.LookPath(cc); err != nil {
+			return "", fmt.Errorf("%s is needed to build for %s, see build/Dockerfile.build", cc, t)
+		}
+		cmd.Env = append(cmd.Env, "CC="+cc)
+	}
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go build: %s\n%s", err.Error(), strings.TrimSpace(output.String()))
+	}
+
+	// the C header written next to the library isn't loaded by Arma
+	os.Remove(strings.TrimSuffix(path, filepath.Ext(path)) + ".h")
+	return path, nil
+}
+
+// buildVersion returns the version to set: version, or else the version of the HEMTT project, followed by the git commit of dir and ".dirty" if there are uncommitted changes
+func buildVersion(version string, project string, dir string) string {
+	if version == "" {
+		version = hemttVersion(project)
+	}
+	if version == "" {
+		version = "DEVELOPMENT"
+	}
+	commit, err := exec.Command("git", "-C", dir, "rev-parse", "--short=8", "HEAD").Output()
+	if err != nil {
+		return version
+	}
+	version += "+" + strings.TrimSpace(string(commit))
+	if status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output(); err == nil && len(bytes.TrimSpace(status)) > 0 {
+		version += ".dirty"
+	}
+	return version
+}
+
+var (
+	// versionPath matches the path of the version file in the [version] table of .hemtt/project.toml
+	versionPath = regexp.MustCompile(`(?ms)^\[version\][^\[]*?^path = "([^"]*)"`)
+	// versionDefine matches the version parts of script_version.hpp
+	versionDefine = regexp.MustCompile(`(?m)^#define (MAJOR|MINOR|PATCH|BUILD) (\d+)`)
+)
+
+// hemttVersion returns MAJOR.MINOR.PATCH, followed by .BUILD if it isn't 0, of the HEMTT project in dir, or "" if there is none
+func hemttVersion(dir string) string {
+	project, err := os.ReadFile(filepath.Join(dir, ".hemtt", "project.toml"))
+	if err != nil {
+		return ""
+	}
+	// HEMTT's default
+	path := "addons/main/script_version.hpp"
+	if match := versionPath.FindSubmatch(project); match != nil {
+		path = string(match[1])
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if err != nil {
+		return ""
+	}
+	version, err := parseScriptVersion(string(data))
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// parseScriptVersion returns the version defined by the contents of a script_version.hpp
+func parseScriptVersion(content string) (string, error) {
+	parts := map[string]string{}
+	for _, match := range versionDefine.FindAllStringSubmatch(content, -1) {
+		parts[match[1]] = match[2]
+	}
+	if parts["MAJOR"] == "" || parts["MINOR"] == "" || parts["PATCH"] == "" {
+		return "", errors.New("missing MAJOR, MINOR or PATCH")
+	}
+	version := parts["MAJOR"] + "." + parts["MINOR"] + "." + parts["PATCH"]
+	if build := parts["BUILD"]; build != "" && build != "0" {
+		version += "." + build
+	}
+	return version, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/indig0fox/a3go/a3verify"
+)
+
+func Test_target_fileName(t *testing.T) {
+	tests := []struct {
+		target target
+		want   string
+	}{
+		{target: target{goos: "linux", goarch: "amd64"}, want: "MyExt_x64.so"},
+		{target: target{goos: "linux", goarch: "386"}, want: "MyExt.so"},
+		{target: target{goos: "windows", goarch: "amd64"}, want: "MyExt_x64.dll"},
+	}
+	for _, tt := range tests {
+		if got := tt.target.fileName("MyExt"); got != tt.want {
+			t.Errorf("%s fileName() = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func Test_parseTargets(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []target
+		wantErr bool
+	}{
+		{list: targetNames(targets), want: targets},
+		{list: "windows/amd64, linux/amd64", want: []target{{goos: "windows", goarch: "amd64"}, {goos: "linux", goarch: "amd64"}}},
+		{list: "linux/arm64", wantErr: true},
+		{list: "windows/386", wantErr: true},
+		{list: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTargets(tt.list)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTargets(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTargets(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func Test_parseScriptVersion(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+		wantErr bool
+	}{
+		{content: "#define MAJOR 0\n#define MINOR 3\n#define PATCH 0\n#define BUILD 0", want: "0.3.0"},
+		{content: "#define MAJOR 1\r\n#define MINOR 2\r\n#define PATCH 3\r\n#define BUILD 4\r\n", want: "1.2.3.4"},
+		{content: "#define MAJOR 1\n#define MINOR 2\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseScriptVersion(tt.content)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScriptVersion(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseScriptVersion(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+
+	if got, want := hemttVersion(filepath.Join("..", "..", "template")), "0.3.0"; got != want {
+		t.Errorf("hemttVersion() = %v, want %v", got, want)
+	}
+}
+
+func Test_build(t *testing.T) {
+	if testing.Short() || runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("builds an extension for linux/amd64")
+	}
+	project := filepath.Join(t.TempDir(), "MyExt")
+	err := newProject(newOptions{
+		name:     "MyExt",
+		template: filepath.Join("..", "..", "template"),
+		output:   project,
+		include:  map[string]map[string]bool{"example": {}, "callback": {}},
+	})
+	if err != nil {
+		t.Fatalf("newProject() error = %v", err)
+	}
+
+	opts := buildOptions{
+		name:    "MyExt",
+		dir:     filepath.Join(project, "MyExt"),
+		dist:    filepath.Join(project, "dist"),
+		version: "1.2.3+test",
+	}
+	path, err := build(opts, target{goos: "linux", goarch: "amd64"})
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+	if path != filepath.Join(project, "dist", "MyExt_x64.so") {
+		t.Errorf("build() = %v, want dist/MyExt_x64.so", path)
+	}
+	if _, err := os.Stat(filepath.Join(project, "dist", "MyExt_x64.h")); err == nil {
+		t.Errorf("build() left the C header in dist")
+	}
+	report, err := a3verify.Verify(path)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !report.OK {
+		t.Errorf("Verify() problems = %q", report.Problems)
+	}
+}
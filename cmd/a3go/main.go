@@ -0,0 +1,51 @@
+// Command a3go creates new extensions from the a3go template and builds them for every platform Arma runs on:
+//
+//	a3go new MyExtension
+//	a3go new -prefix myext -module github.com/me/myext -examples sync,json MyExtension
+//	a3go build ./MyExtension/MyExtension
+//	a3go build -targets linux/amd64,linux/386
+//
+// Run "a3go help" for the commands and "a3go <command> -h" for their flags
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands are the subcommands of a3go. Each returns the exit code
+var commands = []struct {
+	name        string
+	description string
+	run         func(args []string) int
+}{
+	{name: "new", description: "create an extension and addon from the template", run: runNew},
+	{name: "build", description: "build an extension for Linux x86 and x64 and Windows x64", run: runBuild},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	}
+	for _, command := range commands {
+		if command.name == os.Args[1] {
+			os.Exit(command.run(os.Args[2:]))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "a3go: unknown command %q\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: a3go <command> [flags] [arguments]\n\ncommands:\n")
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", command.name, command.description)
+	}
+}
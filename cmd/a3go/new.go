@@ -0,0 +1,417 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// a3goModule is the module path of this repository, which generated extensions require
+const a3goModule = "github.com/indig0fox/a3go"
+
+// placeholder is replaced by the extension name in the paths and contents of the template
+const placeholder = "EXTENSION_NAME"
+
+// templatePrefix is the addon prefix of the template, replaced by the prefix outside of Go files
+const templatePrefix = "a3go"
+
+// feature is an optional part of the template. The template marks the lines belonging to it with
+//
+//	//a3go:<kind> <name>...
+//	...
+//	//a3go:end
+//
+// where kind is "example" or "callback". The lines are kept if any of the names is included
+type feature struct {
+	name        string
+	description string
+	// files are only generated if the feature is included, relative to the template
+	files []string
+}
+
+// examples are the example commands of the template, selected with -examples
+var examples = []feature{
+	{name: "sync", description: `the synchronous "test" command`, files: []string{"addons/main/functions/fn_testSync.sqf"}},
+	{name: "async", description: `the "testAsync" command run in the background`, files: []string{"addons/main/functions/fn_testAsync.sqf"}},
+	{name: "sqlite", description: `the "saveMyCall" command, saving callers to an SQLite database`, files: []string{"addons/main/functions/fn_testSaveCaller.sqf"}},
+	{name: "json", description: `the "returnJSONFromHashMap" command`, files: []string{"addons/main/functions/fn_hashToJson.sqf"}},
+}
+
+// callbacks are the library's callbacks handled by fn_postInit.sqf, selected with -callbacks. Examples bring their own callbacks
+var callbacks = []feature{
+	{name: "log", description: "warnings logged by the extension, written to the RPT"},
+	{name: "pending", description: "results of calls that exceed their time budget"},
+}
+
+// validName matches extension names and prefixes, which are used in file names, SQF function names and config classes
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// newOptions configures a generated project
+type newOptions struct {
+	// name of the extension, used for the Go module directory, the built files and callExtension
+	name string
+	// prefix of the addon and its SQF functions
+	prefix string
+	author string
+	// module path of the extension's Go module
+	module string
+	// a3goVersion is the version of a3go to require. If empty the a3go directory holding the template replaces it
+	a3goVersion string
+	// template is the directory of the template
+	template string
+	// output is the directory to create the project in
+	output string
+	// include lists the names of the included features by kind
+	include map[string]map[string]bool
+}
+
+func runNew(args []string) int {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	prefix := flags.String("prefix", "", "addon and SQF function `prefix`, the lower case name by default")
+	author := flags.String("author", "", "author of the HEMTT project")
+	module := flags.String("module", "", "Go module `path` of the extension, the name by default")
+	a3goVersion := flags.String("a3go-version", "", "`version` of "+a3goModule+" to require instead of replacing it with the a3go directory holding the template")
+	templateDir := flags.String("template", "", "template `directory`, found from the current directory or this command's source by default")
+	output := flags.String("o", "", "output `directory`, ./<name> by default")
+	exampleList := flags.String("examples", "all", "comma separated example commands to include, all or none:\n"+describe(examples))
+	callbackList := flags.String("callbacks", "all", "comma separated callbacks for fn_postInit.sqf to handle, all or none:\n"+describe(callbacks))
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: a3go new [flags] name\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	opts := newOptions{
+		name:        flags.Arg(0),
+		prefix:      *prefix,
+		author:      *author,
+		module:      *module,
+		a3goVersion: *a3goVersion,
+		template:    *templateDir,
+		output:      *output,
+		include:     map[string]map[string]bool{},
+	}
+	var err error
+	if opts.include["example"], err = parseFeatures(*exampleList, examples); err != nil {
+		fmt.Fprintf(os.Stderr, "a3go: -examples: %s\n", err.Error())
+		return 2
+	}
+	if opts.include["callback"], err = parseFeatures(*callbackList, callbacks); err != nil {
+		fmt.Fprintf(os.Stderr, "a3go: -callbacks: %s\n", err.Error())
+		return 2
+	}
+	if opts.template == "" {
+		if opts.template, err = findTemplate(); err != nil {
+			fmt.Fprintf(os.Stderr, "a3go: %s\n", err.Error())
+			return 1
+		}
+	}
+
+	if err := newProject(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "a3go: %s\n", err.Error())
+		return 1
+	}
+	if opts.output == "" {
+		opts.output = opts.name
+	}
+	fmt.Printf("created %s\n\nbuild the extension from %s\n", opts.output, filepath.Join(opts.output, opts.name))
+	return 0
+}
+
+// describe lists features for flag usage
+func describe(features []feature) string {
+	var b strings.Builder
+	for _, f := range features {
+		fmt.Fprintf(&b, "  %s: %s\n", f.name, f.description)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parseFeatures parses a comma separated list of feature names, "all" or "none"
+func parseFeatures(list string, features []feature) (map[string]bool, error) {
+	included := make(map[string]bool, len(features))
+	switch list {
+	case "all":
+		for _, f := range features {
+			included[f.name] = true
+		}
+		return included, nil
+	case "none", "":
+		return included, nil
+	}
+	names := make([]string, 0, len(features))
+	for _, f := range features {
+		names = append(names, f.name)
+	}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range features {
+			found = found || f.name == name
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown name %q, choose from %s", name, strings.Join(names, ", "))
+		}
+		included[name] = true
+	}
+	return included, nil
+}
+
+// findTemplate returns the template directory of the a3go checkout containing the current directory, or else of the checkout this command was built from
+func findTemplate() (string, error) {
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil && modulePath(string(data)) == a3goModule {
+				return filepath.Join(dir, "template"), nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	// without -trimpath the source of this file is in the checkout or the module cache
+	if _, file, _, ok := runtime.Caller(0); ok && filepath.IsAbs(file) {
+		dir := filepath.Join(filepath.Dir(file), "..", "..", "template")
+		if _, err := os.Stat(filepath.Join(dir, placeholder, "go.mod")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", errors.New("can't find the a3go template, run from an a3go checkout or pass -template")
+}
+
+// modulePath returns the module path declared by the contents of a go.mod file
+func modulePath(goMod string) string {
+	for _, line := range strings.Split(goMod, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// newProject writes a project generated from the template to opts.output
+func newProject(opts newOptions) error {
+	if !validName.MatchString(opts.name) {
+		return fmt.Errorf("invalid name %q, use letters, digits and underscores, starting with a letter", opts.name)
+	}
+	if opts.prefix == "" {
+		opts.prefix = strings.ToLower(opts.name)
+	}
+	if !validName.MatchString(opts.prefix) {
+		return fmt.Errorf("invalid prefix %q, use letters, digits and underscores, starting with a letter", opts.prefix)
+	}
+	if opts.module == "" {
+		opts.module = opts.name
+	}
+	if opts.output == "" {
+		opts.output = opts.name
+	}
+	if entries, err := os.ReadDir(opts.output); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", opts.output)
+	}
+	if _, err := os.Stat(filepath.Join(opts.template, placeholder, "go.mod")); err != nil {
+		return fmt.Errorf("%s is not an a3go template: %s", opts.template, err.Error())
+	}
+
+	excluded := map[string]bool{}
+	for _, f := range examples {
+		for _, file := range f.files {
+			excluded[file] = !opts.include["example"][f.name]
+		}
+	}
+
+	return filepath.WalkDir(opts.template, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(opts.template, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			// build output of HEMTT
+			if rel == ".hemttout" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// built extensions, only the placeholder is kept
+		if excluded[rel] || strings.HasPrefix(rel, "dist/") && rel != "dist/.gitkeep" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data, keep, err := generate(rel, data, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+		if !keep {
+			return nil
+		}
+		target := filepath.Join(opts.output, filepath.FromSlash(strings.ReplaceAll(rel, placeholder, opts.name)))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+// generate returns the contents of the template file at rel for a project, and false if the file is left out
+func generate(rel string, data []byte, opts newOptions) ([]byte, bool, error) {
+	if rel == placeholder+"/go.sum" {
+		return data, true, nil
+	}
+	content, err := strip(string(data), opts.include)
+	if err != nil {
+		return nil, false, err
+	}
+	content = strings.ReplaceAll(content, placeholder, opts.name)
+
+	switch {
+	case rel == placeholder+"/go.mod":
+		content, err = rewriteGoMod(content, opts)
+		if err != nil {
+			return nil, false, err
+		}
+	case strings.HasSuffix(rel, ".go"):
+		// a file whose declarations all belong to left out features
+		file, err := parser.ParseFile(token.NewFileSet(), rel, content, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, false, err
+		}
+		empty := true
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			empty = empty && ok && gen.Tok == token.IMPORT
+		}
+		if empty {
+			return nil, false, nil
+		}
+		formatted, err := format.Source([]byte(content))
+		if err != nil {
+			return nil, false, err
+		}
+		return formatted, true, nil
+	default:
+		content = strings.ReplaceAll(content, templatePrefix, opts.prefix)
+		if rel == ".hemtt/project.toml" {
+			content = replaceSetting(content, "name", opts.name)
+			content = replaceSetting(content, "author", opts.author)
+		}
+	}
+	return []byte(content), true, nil
+}
+
+// strip removes the marker lines of features and the lines of features that aren't included
+func strip(content string, include map[string]map[string]bool) (string, error) {
+	var b strings.Builder
+	inFeature, keep := false, true
+	for i, line := range strings.SplitAfter(content, "\n") {
+		marker, ok := strings.CutPrefix(strings.TrimSpace(line), "//a3go:")
+		if !ok {
+			if keep {
+				b.WriteString(line)
+			}
+			continue
+		}
+		fields := strings.Fields(marker)
+		switch {
+		case marker == "end":
+			if !inFeature {
+				return "", fmt.Errorf("line %d: //a3go:end without a feature", i+1)
+			}
+			inFeature, keep = false, true
+		case inFeature:
+			return "", fmt.Errorf("line %d: features can't be nested", i+1)
+		case len(fields) < 2 || include[fields[0]] == nil:
+			return "", fmt.Errorf("line %d: invalid marker %q", i+1, strings.TrimSpace(line))
+		default:
+			inFeature, keep = true, false
+			for _, name := range fields[1:] {
+				keep = keep || include[fields[0]][name]
+			}
+		}
+	}
+	if inFeature {
+		return "", errors.New("missing //a3go:end")
+	}
+	return b.String(), nil
+}
+
+// rewriteGoMod sets the module path and points the a3go requirement at a3goVersion or the a3go directory holding the template
+func rewriteGoMod(content string, opts newOptions) (string, error) {
+	templateModule, err := filepath.Abs(filepath.Join(opts.template, placeholder))
+	if err != nil {
+		return "", err
+	}
+	projectModule, err := filepath.Abs(filepath.Join(opts.output, opts.name))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 2 && fields[0] == "module":
+			line = "module " + opts.module + "\n"
+		case len(fields) > 1 && fields[0] == "replace" && fields[1] == a3goModule:
+			if opts.a3goVersion != "" {
+				continue
+			}
+			// replacements are relative to the module, so are moved with it
+			target := fields[len(fields)-1]
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(templateModule, target)
+			}
+			// a relative path climbing to the root only works on this machine, like an absolute one
+			if rel, err := filepath.Rel(projectModule, target); err == nil && !climbsToRoot(projectModule, rel) {
+				target = filepath.ToSlash(rel)
+				if !strings.HasPrefix(target, "../") {
+					target = "./" + target
+				}
+			}
+			line = fmt.Sprintf("replace %s => %s\n", a3goModule, target)
+		case len(fields) == 3 && fields[0] == "require" && fields[1] == a3goModule && opts.a3goVersion != "":
+			line = fmt.Sprintf("require %s %s\n", a3goModule, opts.a3goVersion)
+		}
+		b.WriteString(line)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// climbsToRoot reports whether the relative path rel leaves every directory of the absolute path dir
+func climbsToRoot(dir string, rel string) bool {
+	depth := len(strings.Split(strings.Trim(strings.TrimPrefix(dir, filepath.VolumeName(dir)), string(filepath.Separator)), string(filepath.Separator)))
+	return strings.Count(filepath.ToSlash(rel)+"/", "../") >= depth
+}
+
+// replaceSetting sets a top level string setting of a TOML file
+func replaceSetting(content string, key string, value string) string {
+	setting := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + ` = ".*"$`)
+	return setting.ReplaceAllLiteralString(content, fmt.Sprintf("%s = %q", key, value))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_strip(t *testing.T) {
+	include := map[string]map[string]bool{
+		"example":  {"sync": true},
+		"callback": {},
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "no markers", content: "a\nb\n", want: "a\nb\n"},
+		{name: "included", content: "a\n\t//a3go:example sync\nb\n\t//a3go:end\nc", want: "a\nb\nc"},
+		{name: "left out", content: "a\n//a3go:example json\nb\n//a3go:end\nc\n", want: "a\nc\n"},
+		{name: "any name", content: "//a3go:example json sync\nb\n//a3go:end\n", want: "b\n"},
+		{name: "other kind", content: "//a3go:callback sync\nb\n//a3go:end\n", want: ""},
+		{name: "nested", content: "//a3go:example sync\n//a3go:example json\n//a3go:end\n//a3go:end\n", wantErr: true},
+		{name: "unterminated", content: "//a3go:example sync\nb\n", wantErr: true},
+		{name: "stray end", content: "a\n//a3go:end\n", wantErr: true},
+		{name: "unknown kind", content: "//a3go:handler sync\n//a3go:end\n", wantErr: true},
+		{name: "missing names", content: "//a3go:example\n//a3go:end\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := strip(tt.content, include)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("strip() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("strip() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rewriteGoMod(t *testing.T) {
+	content := "module github.com/indig0fox/a3go/template\n\ngo 1.21\n\nrequire github.com/indig0fox/a3go v0.0.0-unpublished\n\nreplace github.com/indig0fox/a3go v0.0.0-unpublished => ../..\n\n"
+	tests := []struct {
+		name string
+		opts newOptions
+		want string
+	}{
+		{
+			name: "replace",
+			opts: newOptions{name: "MyExt", module: "github.com/me/myext", template: "a3go/template", output: "MyExt"},
+			want: "module github.com/me/myext\n\ngo 1.21\n\nrequire github.com/indig0fox/a3go v0.0.0-unpublished\n\nreplace github.com/indig0fox/a3go => ../../a3go\n",
+		},
+		{
+			name: "inside the checkout",
+			opts: newOptions{name: "MyExt", module: "MyExt", template: "a3go/template", output: "a3go/extensions/MyExt"},
+			want: "module MyExt\n\ngo 1.21\n\nrequire github.com/indig0fox/a3go v0.0.0-unpublished\n\nreplace github.com/indig0fox/a3go => ../../..\n",
+		},
+		{
+			name: "version",
+			opts: newOptions{name: "MyExt", module: "MyExt", a3goVersion: "v0.4.0", template: "a3go/template", output: "MyExt"},
+			want: "module MyExt\n\ngo 1.21\n\nrequire github.com/indig0fox/a3go v0.4.0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rewriteGoMod(content, tt.opts)
+			if err != nil {
+				t.Fatalf("rewriteGoMod() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("rewriteGoMod() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newProject(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "MyExt")
+	opts := newOptions{
+		name:     "MyExt",
+		prefix:   "myext",
+		author:   "Me",
+		module:   "github.com/me/myext",
+		template: filepath.Join("..", "..", "template"),
+		output:   output,
+		include: map[string]map[string]bool{
+			"example":  {"json": true},
+			"callback": {"pending": true},
+		},
+	}
+	if err := newProject(opts); err != nil {
+		t.Fatalf("newProject() error = %v", err)
+	}
+
+	read := func(rel string) string {
+		data, err := os.ReadFile(filepath.Join(output, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("newProject() didn't write %s: %v", rel, err)
+		}
+		return string(data)
+	}
+	for _, rel := range []string{"addons/main/functions/fn_testSync.sqf", "addons/main/functions/fn_testSaveCaller.sqf", "EXTENSION_NAME"} {
+		if _, err := os.Stat(filepath.Join(output, filepath.FromSlash(rel))); err == nil {
+			t.Errorf("newProject() wrote %s, want it left out", rel)
+		}
+	}
+	files := map[string][]string{
+		"MyExt/main.go":                         {"SetArgsFunction(ReturnJSONFromHashMapArgs)", `NewModuleLogFile("MyExt.log"`},
+		"MyExt/a3calls.go":                      {`"encoding/json"`},
+		"MyExt/go.mod":                          {"module github.com/me/myext\n", "replace github.com/indig0fox/a3go => "},
+		"addons/main/config.cpp":                {"class myext_main", `file = "x\myext\addons\main\functions";`, "class hashToJson {};"},
+		"addons/main/functions/fn_postInit.sqf": {`_extension isEqualTo "MyExt"`, `case "pending"`, "myext_fnc_parseResponse"},
+		"addons/main/$PBOPREFIX$":               {`x\myext\addons\main`},
+		".hemtt/project.toml":                   {`name = "MyExt"`, `author = "Me"`, `prefix = "myext"`},
+	}
+	for rel, wants := range files {
+		content := read(rel)
+		for _, want := range wants {
+			if !strings.Contains(content, want) {
+				t.Errorf("newProject() %s doesn't contain %q", rel, want)
+			}
+		}
+		for _, unwanted := range []string{"//a3go:", "EXTENSION_NAME", "testAsync", "saveMyCall", "sqlite", `case "log"`} {
+			if strings.Contains(content, unwanted) {
+				t.Errorf("newProject() %s contains %q", rel, unwanted)
+			}
+		}
+	}
+
+	if err := newProject(opts); err == nil {
+		t.Errorf("newProject() error = nil, want an error for an existing project")
+	}
+	opts.name, opts.output = "my-ext", filepath.Join(t.TempDir(), "invalid")
+	if err := newProject(opts); err == nil {
+		t.Errorf("newProject() error = nil, want an error for an invalid name")
+	}
+}
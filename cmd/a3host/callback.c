@@ -0,0 +1,9 @@
+//go:build linux
+
+#include "_cgo_export.h"
+
+// hostCallback is registered with RVExtensionRegisterCallback and prints every callback the extension sends
+int hostCallback(char const *name, char const *function, char const *data)
+{
+	return printCallback((char *)name, (char *)function, (char *)data);
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// printer serializes the output of the session and of callbacks, which arrive from the extension's threads
+type printer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var output = &printer{w: os.Stdout}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, format, args...)
+}
+
+//export printCallback
+func printCallback(name *C.char, function *C.char, data *C.char) C.int {
+	output.printf("callback %s %s %s\n", C.GoString(name), C.GoString(function), C.GoString(data))
+	return 0
+}
@@ -0,0 +1,168 @@
+//go:build linux
+
+package main
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdlib.h>
+
+typedef int (*extensionCallback)(char const *name, char const *function, char const *data);
+typedef void (*versionFunc)(char *output, size_t outputSize);
+typedef void (*contextFunc)(char **args, int argsCnt);
+typedef void (*callFunc)(char *output, size_t outputSize, char const *function);
+typedef void (*callArgsFunc)(char *output, size_t outputSize, char const *function, char **argv, int argc);
+typedef void (*registerCallbackFunc)(extensionCallback fnc);
+
+int hostCallback(char const *name, char const *function, char const *data);
+
+static inline void callVersion(void *fnc, char *output, size_t outputSize)
+{
+	((versionFunc)fnc)(output, outputSize);
+}
+
+static inline void callContext(void *fnc, char **args, int argsCnt)
+{
+	((contextFunc)fnc)(args, argsCnt);
+}
+
+static inline void callExtension(void *fnc, char *output, size_t outputSize, char const *function)
+{
+	((callFunc)fnc)(output, outputSize, function);
+}
+
+static inline void callExtensionArgs(void *fnc, char *output, size_t outputSize, char const *function, char **argv, int argc)
+{
+	((callArgsFunc)fnc)(output, outputSize, function, argv, argc);
+}
+
+static inline void registerHostCallback(void *fnc)
+{
+	((registerCallbackFunc)fnc)(hostCallback);
+}
+*/
+import "C"
+import (
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/indig0fox/a3go/a3verify"
+)
+
+// versionOutputSize is the size of the output buffer Arma passes to RVExtensionVersion
+const versionOutputSize = 32
+
+// library is an extension opened with dlopen
+type library struct {
+	path    string
+	handle  unsafe.Pointer
+	symbols map[string]unsafe.Pointer
+}
+
+// openLibrary loads the extension at path and looks up its exports
+func openLibrary(path string) (*library, error) {
+	// dlopen searches the library path for names without a slash
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %s", path, err.Error())
+	}
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	handle := C.dlopen(cPath, C.RTLD_NOW|C.RTLD_LOCAL)
+	if handle == nil {
+		return nil, fmt.Errorf("error loading %s: %s", path, C.GoString(C.dlerror()))
+	}
+
+	lib := &library{path: path, handle: handle, symbols: make(map[string]unsafe.Pointer)}
+	for _, e := range a3verify.Exports {
+		cName := C.CString(e.Name)
+		symbol := C.dlsym(handle, cName)
+		C.free(unsafe.Pointer(cName))
+		if symbol != nil {
+			lib.symbols[e.Name] = symbol
+		}
+	}
+	return lib, nil
+}
+
+// missing returns the exports that were not found, and whether any of them is required
+func (l *library) missing() (names []string, required bool) {
+	for _, e := range a3verify.Exports {
+		if _, ok := l.symbols[e.Name]; !ok {
+			names = append(names, e.Name)
+			required = required || e.Required
+		}
+	}
+	return names, required
+}
+
+// registerCallback registers the callback that prints callbacks, if the extension exports RVExtensionRegisterCallback
+func (l *library) registerCallback() bool {
+	symbol, ok := l.symbols["RVExtensionRegisterCallback"]
+	if ok {
+		C.registerHostCallback(symbol)
+	}
+	return ok
+}
+
+// version calls RVExtensionVersion
+func (l *library) version() string {
+	output := (*C.char)(C.calloc(versionOutputSize, 1))
+	defer C.free(unsafe.Pointer(output))
+	C.callVersion(l.symbols["RVExtensionVersion"], output, versionOutputSize)
+	return C.GoString(output)
+}
+
+// sendContext calls RVExtensionContext, if the extension exports it
+func (l *library) sendContext(args []string) bool {
+	symbol, ok := l.symbols["RVExtensionContext"]
+	if !ok {
+		return false
+	}
+	argv, free := cStrings(args)
+	defer free()
+	C.callContext(symbol, argv, C.int(len(args)))
+	return true
+}
+
+// call calls RVExtension with an output buffer of outputSize bytes
+func (l *library) call(input string, outputSize int) string {
+	output := (*C.char)(C.calloc(C.size_t(outputSize), 1))
+	defer C.free(unsafe.Pointer(output))
+	cInput := C.CString(input)
+	defer C.free(unsafe.Pointer(cInput))
+	C.callExtension(l.symbols["RVExtension"], output, C.size_t(outputSize), cInput)
+	return C.GoString(output)
+}
+
+// callArgs calls RVExtensionArgs with an output buffer of outputSize bytes
+func (l *library) callArgs(function string, args []string, outputSize int) string {
+	output := (*C.char)(C.calloc(C.size_t(outputSize), 1))
+	defer C.free(unsafe.Pointer(output))
+	cFunction := C.CString(function)
+	defer C.free(unsafe.Pointer(cFunction))
+	argv, free := cStrings(args)
+	defer free()
+	C.callExtensionArgs(l.symbols["RVExtensionArgs"], output, C.size_t(outputSize), cFunction, argv, C.int(len(args)))
+	return C.GoString(output)
+}
+
+// cStrings copies values to a C array of C strings, which must be freed with the returned function
+func cStrings(values []string) (**C.char, func()) {
+	if len(values) == 0 {
+		return nil, func() {}
+	}
+	size := C.size_t(len(values)) * C.size_t(unsafe.Sizeof(uintptr(0)))
+	array := (**C.char)(C.malloc(size))
+	items := unsafe.Slice(array, len(values))
+	for index, value := range values {
+		items[index] = C.CString(value)
+	}
+	return array, func() {
+		for _, item := range items {
+			C.free(unsafe.Pointer(item))
+		}
+		C.free(unsafe.Pointer(array))
+	}
+}
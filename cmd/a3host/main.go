@@ -0,0 +1,74 @@
+//go:build linux
+
+// Command a3host loads a built extension with dlopen and calls it the way the engine does, to smoke test it without an Arma server. It checks the extension's exports, registers a callback that prints every callback it receives and calls RVExtensionVersion, then reads commands from a script or an interactive prompt:
+//
+//	a3host ./EXTENSION_NAME_x64.so
+//	a3host -script smoke.txt -output-size 20480 ./EXTENSION_NAME_x64.so
+//
+// Every call is preceded by RVExtensionContext, as the engine does. Run "help" at the prompt for the commands
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultOutputSize is the output buffer size the engine passes to RVExtension and RVExtensionArgs
+const defaultOutputSize = 10240
+
+func main() {
+	script := flag.String("script", "", "run commands from `file` instead of the prompt, - for stdin")
+	outputSize := flag.Int("output-size", defaultOutputSize, "size in `bytes` of the output buffer, including the null terminator")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: a3host [flags] extension.so\n\nflags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s\n", usage)
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *outputSize < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *script, *outputSize); err != nil {
+		fmt.Fprintf(os.Stderr, "a3host: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(path string, script string, outputSize int) error {
+	lib, err := openLibrary(path)
+	if err != nil {
+		return err
+	}
+	if missing, required := lib.missing(); required {
+		return fmt.Errorf("%s is missing exports: %s", path, strings.Join(missing, ", "))
+	} else if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "a3host: %s is missing optional exports: %s\n", path, strings.Join(missing, ", "))
+	}
+
+	// the engine registers its callback before asking for the version
+	lib.registerCallback()
+	output.printf("loaded %s version %s\n", path, lib.version())
+
+	s := &session{
+		lib:        lib,
+		outputSize: outputSize,
+		context:    []string{"0", "", "a3host", "a3host", "0"},
+	}
+	switch script {
+	case "":
+		return s.run(os.Stdin, true)
+	case "-":
+		return s.run(os.Stdin, false)
+	default:
+		file, err := os.Open(script)
+		if err != nil {
+			return fmt.Errorf("error opening script: %s", err.Error())
+		}
+		defer file.Close()
+		return s.run(file, false)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Test_template builds the template with -buildmode=c-shared and drives it through a3host, which runs a second Go runtime in the same process
+func Test_template(t *testing.T) {
+	if testing.Short() || runtime.GOARCH != "amd64" {
+		t.Skip("builds the template for linux/amd64")
+	}
+	dir := t.TempDir()
+	extension := filepath.Join(dir, "EXTENSION_NAME_x64.so")
+	host := filepath.Join(dir, "a3host")
+
+	builds := []*exec.Cmd{
+		exec.Command("go", "build", "-buildmode=c-shared", "-o", extension, "."),
+		exec.Command("go", "build", "-o", host, "."),
+	}
+	builds[0].Dir = filepath.Join("..", "..", "template", "EXTENSION_NAME")
+	for _, cmd := range builds {
+		cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s error = %v\n%s", strings.Join(cmd.Args, " "), err, out)
+		}
+	}
+
+	cmd := exec.Command(host, "-script", "-", extension)
+	cmd.Stdin = strings.NewReader(strings.Join([]string{
+		"context 76561198000000001 \"\" co10_test \"My Server\"",
+		"call test|a|b",
+		`args test "a" 5`,
+		"args missing",
+		"sleep 200ms",
+		"quit",
+	}, "\n"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("a3host error = %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"loaded " + extension + " version 1.0.0\n",
+		`< ["Called by 76561198000000001", ["a", "b"]]`,
+		`< ["Called by 76561198000000001", "test", ["a" "5"]]`,
+		`< ["error", "NOT_REGISTERED", "command missing not registered", [], false]`,
+		`callback EXTENSION_NAME log ["WARN","call failed"`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("a3host output is missing %q\n%s", want, out)
+		}
+	}
+}
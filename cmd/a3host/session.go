@@ -0,0 +1,194 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usage describes the commands of a session
+const usage = `commands:
+  call <input>                  call RVExtension with input as is, i.e. call test|a|b
+  args <function> [arg ...]     call RVExtensionArgs, args are sent as Arma sends them, i.e. args save "name" 5 [1,2]
+  context <steamID> <fileSource> <mission> <server> [remoteExecutedOwner]
+                                set the context sent with RVExtensionContext before every call
+  size <bytes>                  set the output buffer size, including the null terminator
+  version                       call RVExtensionVersion
+  sleep <duration>              wait for callbacks, i.e. sleep 500ms
+  help                          show this help
+  quit                          exit
+Lines starting with # are ignored. Values containing spaces can be quoted, with "" for a quote.`
+
+// errQuit is returned by exec for the quit command
+var errQuit = fmt.Errorf("quit")
+
+// session sends calls to a library the way the engine does
+type session struct {
+	lib        *library
+	outputSize int
+	// context is sent with RVExtensionContext before every call
+	context []string
+}
+
+// run executes the commands read from r. Scripts stop at the first error, the REPL reports it and continues
+func (s *session) run(r io.Reader, interactive bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	number := 0
+	for {
+		if interactive {
+			output.printf("a3host> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !interactive {
+			output.printf("> %s\n", line)
+		}
+		err := s.exec(line)
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			if !interactive {
+				return fmt.Errorf("line %d: %s", number, err.Error())
+			}
+			output.printf("error: %s\n", err.Error())
+		}
+	}
+	return scanner.Err()
+}
+
+// exec runs a single command
+func (s *session) exec(line string) error {
+	name, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	switch name {
+	case "call":
+		s.lib.sendContext(s.context)
+		output.printf("< %s\n", s.lib.call(rest, s.outputSize))
+	case "args":
+		fields, err := splitFields(rest)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("args needs a function")
+		}
+		s.lib.sendContext(s.context)
+		output.printf("< %s\n", s.lib.callArgs(unquote(fields[0]), fields[1:], s.outputSize))
+	case "context":
+		fields, err := splitFields(rest)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			quoted := make([]string, len(s.context))
+			for index, field := range s.context {
+				quoted[index] = quote(field)
+			}
+			output.printf("context %s\n", strings.Join(quoted, " "))
+			return nil
+		}
+		if len(fields) < 4 || len(fields) > 5 {
+			return fmt.Errorf("context needs a steamID, fileSource, mission, server and optional remoteExecutedOwner")
+		}
+		context := make([]string, len(fields))
+		for index, field := range fields {
+			context[index] = unquote(field)
+		}
+		s.context = context
+	case "size":
+		size, err := strconv.Atoi(rest)
+		if err != nil || size < 1 {
+			return fmt.Errorf("invalid output size %s", rest)
+		}
+		s.outputSize = size
+	case "version":
+		output.printf("< %s\n", s.lib.version())
+	case "sleep":
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return fmt.Errorf("invalid duration %s", rest)
+		}
+		time.Sleep(d)
+	case "help":
+		output.printf("%s\n", usage)
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %s, see help", name)
+	}
+	return nil
+}
+
+// splitFields splits a line on spaces, keeping quoted strings and arrays together as they are written
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	depth := 0
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted:
+			if c == '"' {
+				if i+1 < len(line) && line[i+1] == '"' {
+					field.WriteString(`""`)
+					i++
+					continue
+				}
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected ] at %d", i)
+			}
+		case (c == ' ' || c == '\t') && depth == 0:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			continue
+		}
+		field.WriteByte(c)
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated string")
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unterminated array")
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// quote wraps a value in quotes, doubling the quotes in it
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
+
+// unquote removes the quotes around a field, if any
+func unquote(field string) string {
+	if len(field) >= 2 && strings.HasPrefix(field, `"`) && strings.HasSuffix(field, `"`) {
+		return strings.ReplaceAll(field[1:len(field)-1], `""`, `"`)
+	}
+	return field
+}
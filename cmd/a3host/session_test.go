@@ -0,0 +1,57 @@
+//go:build linux
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_splitFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "words", line: "save  5\ttrue", want: []string{"save", "5", "true"}},
+		{name: "quoted", line: `save "my name" ""`, want: []string{"save", `"my name"`, `""`}},
+		{name: "escaped quote", line: `"say ""hi"""`, want: []string{`"say ""hi"""`}},
+		{name: "array", line: `save [1, "a b", [2, 3]] x`, want: []string{"save", `[1, "a b", [2, 3]]`, "x"}},
+		{name: "bracket in string", line: `"[" x`, want: []string{`"["`, "x"}},
+		{name: "unterminated string", line: `save "name`, wantErr: true},
+		{name: "unterminated array", line: "save [1, 2", wantErr: true},
+		{name: "unexpected bracket", line: "save 1]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitFields(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitFields() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_unquote(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: `"my mission"`, want: "my mission"},
+		{field: `"say ""hi"""`, want: `say "hi"`},
+		{field: `""`, want: ""},
+		{field: "save", want: "save"},
+	}
+	for _, tt := range tests {
+		if got := unquote(tt.field); got != tt.want {
+			t.Errorf("unquote(%s) = %v, want %v", tt.field, got, tt.want)
+		}
+		if got := unquote(quote(tt.want)); got != tt.want {
+			t.Errorf("unquote(quote(%s)) = %v", tt.want, got)
+		}
+	}
+}
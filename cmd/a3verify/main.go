@@ -0,0 +1,72 @@
+// Command a3verify checks that built extensions can be loaded by Arma, which only reports "extension not found" otherwise. For each DLL or .so it checks the RVExtension exports, the architecture, the "_x64" file name suffix and, for 32-bit Windows, the stdcall decoration of the exports:
+//
+//	a3verify ./template/dist/*
+//	a3verify -json ./template/dist/EXTENSION_NAME_x64.dll > report.json
+//
+// It exits with status 1 if any artifact can't be loaded
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/indig0fox/a3go/a3verify"
+)
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "write the reports as a JSON array")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: a3verify [-json] artifact...\n\nflags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ok := true
+	reports := make([]*a3verify.Report, 0, flag.NArg())
+	for _, path := range flag.Args() {
+		report, err := a3verify.Verify(path)
+		if err != nil {
+			report = &a3verify.Report{Path: path, Problems: []string{err.Error()}, Warnings: []string{}}
+		}
+		ok = ok && report.OK
+		reports = append(reports, report)
+	}
+
+	if *jsonOutput {
+		if err := a3verify.WriteJSON(os.Stdout, reports); err != nil {
+			fmt.Fprintf(os.Stderr, "a3verify: %s\n", err.Error())
+			os.Exit(1)
+		}
+	} else {
+		for _, report := range reports {
+			writeReport(report)
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// writeReport writes a report for people
+func writeReport(report *a3verify.Report) {
+	status := "ok"
+	if !report.OK {
+		status = "FAIL"
+	}
+	if report.Format == "" {
+		fmt.Printf("%s %s\n", status, report.Path)
+	} else {
+		fmt.Printf("%s %s (%s %s)\n", status, report.Path, report.Format, report.Arch)
+	}
+	for _, problem := range report.Problems {
+		fmt.Printf("  error: %s\n", problem)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Printf("  warning: %s\n", warning)
+	}
+}
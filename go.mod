@@ -1,3 +1,3 @@
 module github.com/indig0fox/a3go
 
-go 1.20
+go 1.21
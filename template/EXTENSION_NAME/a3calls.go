@@ -1,17 +1,28 @@
 package main
 
 import (
+	//a3go:example sqlite
 	"database/sql"
+	//a3go:end
+	//a3go:example json
 	"encoding/json"
+	//a3go:end
 	"fmt"
+	//a3go:example sqlite
 	"path/filepath"
+	//a3go:end
+	//a3go:example sync async
 	"strings"
+	//a3go:end
 
 	"github.com/indig0fox/a3go/a3interface"
+	//a3go:example sqlite
 	"github.com/indig0fox/a3go/assemblyfinder"
 	_ "github.com/mattn/go-sqlite3"
+	//a3go:end
 )
 
+//a3go:example sync async
 func ReceiveTestCommand(
 	ctx a3interface.ArmaExtensionContext,
 	data string,
@@ -45,6 +56,9 @@ func ReceiveTestCommandArgs(
 	), nil
 }
 
+//a3go:end
+
+//a3go:example json
 func ReturnJSONFromHashMapArgs(
 	ctx a3interface.ArmaExtensionContext,
 	command string,
@@ -67,6 +81,9 @@ func ReturnJSONFromHashMapArgs(
 	return fmt.Sprintf(`%s`, JSONString), nil
 }
 
+//a3go:end
+
+//a3go:example sqlite
 func SaveCallerArgs(
 	ctx a3interface.ArmaExtensionContext,
 	command string,
@@ -121,3 +138,5 @@ func SaveCaller(ctx a3interface.ArmaExtensionContext, data string) (string, erro
 
 	return `["Logged row to database!"]`, nil
 }
+
+//a3go:end
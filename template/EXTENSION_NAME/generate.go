@@ -0,0 +1,87 @@
+//go:build a3gen
+
+// The generators link a3sqf and a3doc, which the extension loaded by Arma doesn't need, so they are only built with the a3gen tag:
+//
+//	go run -tags a3gen . sqf
+//	go run -tags a3gen . manifest -o manifest.json
+//	go run -tags a3gen . docs -o COMMANDS.md
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/indig0fox/a3go/a3doc"
+	"github.com/indig0fox/a3go/a3interface"
+	"github.com/indig0fox/a3go/a3sqf"
+)
+
+func init() {
+	subcommands["sqf"] = generateSQF
+	subcommands["manifest"] = func(args []string) int { return writeManifest("manifest", args) }
+	subcommands["docs"] = func(args []string) int { return writeManifest("docs", args) }
+}
+
+// generateSQF writes an addon with an SQF wrapper for every command registered in init, and a dispatcher for the callbacks they send, returning the exit code
+func generateSQF(args []string) int {
+	flags := flag.NewFlagSet("sqf", flag.ExitOnError)
+	project := flags.String("project", "..", "directory of the HEMTT project")
+	output := flags.String("o", "", "addon directory, addons/commands of the project by default")
+	flags.Parse(args)
+
+	opts, err := a3sqf.ReadProject(*project)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	opts.Extension = "EXTENSION_NAME"
+	opts.Component = "commands"
+	opts.Callbacks = map[string]string{
+		//a3go:callback log
+		"log": "[level, message, attributes] of warnings logged by the extension",
+		//a3go:end
+	}
+	if *output == "" {
+		*output = filepath.Join(*project, "addons", opts.Component)
+	}
+	if err := a3sqf.WriteAddon(*output, a3interface.Default().Registrations(), opts); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Printf("wrote %s\n", *output)
+	return 0
+}
+
+// writeManifest writes the manifest of the commands registered in init as JSON for "manifest", or as Markdown for mission makers for "docs", returning the exit code
+func writeManifest(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	output := flags.String("o", "", "write to `file` instead of standard output")
+	flags.Parse(args)
+
+	// the name of this executable may differ from the name Arma loads the extension by
+	a3interface.Default().SetExtensionName("EXTENSION_NAME")
+	w := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	var err error
+	if command == "docs" {
+		err = a3doc.WriteMarkdown(w, a3interface.Default().Manifest())
+	} else {
+		err = a3interface.Default().WriteManifest(w)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
@@ -1,13 +1,12 @@
 module github.com/indig0fox/a3go/template
 
-go 1.20
+go 1.21
 
-require github.com/indig0fox/a3go/a3interface v0.0.0-unpublished
+require github.com/indig0fox/a3go v0.0.0-unpublished
 
-replace github.com/indig0fox/a3go/a3interface v0.0.0-unpublished => ../../a3interface
-
-require github.com/indig0fox/a3go/assemblyfinder v0.0.0-unpublished
+replace github.com/indig0fox/a3go v0.0.0-unpublished => ../..
 
+//a3go:example sqlite
 require github.com/mattn/go-sqlite3 v1.14.17
 
-replace github.com/indig0fox/a3go/assemblyfinder v0.0.0-unpublished => ../../assemblyfinder
+//a3go:end
@@ -5,23 +5,95 @@ package main
 // #include <string.h>
 import "C"
 import (
+	"flag"
 	"fmt"
+	"log/slog"
+	"os"
 	"path/filepath"
+	"regexp"
+	//a3go:example sqlite
+	"time"
+	//a3go:end
 
 	"github.com/indig0fox/a3go/a3interface"
 	"github.com/indig0fox/a3go/assemblyfinder"
 )
 
-var a3ErrorChannel chan []string = make(chan []string)
-
 // we can use the assemblyfinder library to get the absolute path to our DLL. this is useful for finding files relative to the DLL, like sidecar config files in the addon's root directory.
 var dllAbsPath string = assemblyfinder.GetModulePath()
 var addonDirectory string = filepath.Dir(dllAbsPath)
 
+// version is replaced by "a3go build" with the version in script_version.hpp and the git commit, like 0.3.0+1a2b3c4d
+var version = "1.0.0"
+
 func init() {
-	a3interface.SetVersion("1.0.0")
-	a3interface.RegisterErrorChan(a3ErrorChannel)
+	a3interface.SetVersion(version)
+
+	// LOGGING
+	// the library only logs warnings and errors to stderr by default. here we also write everything to a rotating log file next to our DLL, and forward warnings to Arma so fn_postInit.sqf can write them to the RPT with diag_log
+	logFile, err := a3interface.NewModuleLogFile("EXTENSION_NAME.log", 10<<20, 3)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		a3interface.Default().SetLogger(slog.New(a3interface.NewMultiLogHandler(
+			slog.NewTextHandler(logFile, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			//a3go:callback log
+			a3interface.NewCallbackLogHandler("EXTENSION_NAME", "log", slog.LevelWarn),
+			//a3go:end
+		)))
+	}
+
+	// EVENTS
+	// subscribe to errors and panics from any command. events are dropped rather than blocking the extension if we fall behind, so the subscription's buffer only needs to be large enough for bursts
+	// the library already logs failed calls, so here they only go to the log file at debug level. event.Call.ArmaContext holds the caller, which is kept out of the logs
+	events := a3interface.Default().Subscribe(64, a3interface.EventError, a3interface.EventPanic)
+	go func() {
+		for event := range events.C {
+			a3interface.Default().Logger().Debug("event",
+				slog.String("type", string(event.Type)),
+				slog.String("command", event.Call.Command),
+				slog.String("message", event.Message),
+			)
+		}
+	}()
+
+	// RECORDING
+	// start the server with EXTENSION_NAME_RECORD=path/to/recording.jsonl to record every call, response and callback. run "EXTENSION_NAME replay path/to/recording.jsonl" to replay it through this build and see what changed
+	if path := os.Getenv("EXTENSION_NAME_RECORD"); path != "" {
+		recorder, err := a3interface.NewFileRecorder(path)
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			a3interface.Default().SetRecorder(recorder)
+		}
+	}
+
+	registerCommands(a3interface.Default())
+}
+
+// registerCommands registers the commands of the extension on ext. init registers them on the default Extension Arma calls, and replay on a fresh one
+func registerCommands(ext *a3interface.Extension) {
+	// METRICS
+	// "EXTENSION_NAME" callExtension "stats" returns call counts, errors and latencies for every command
+	ext.RegisterStatsCommand("stats")
+
+	// RATE LIMITS
+	// each client that remote executes calls may make 20 calls per second, in bursts of up to 50. calls over the limit are rejected with a RATE_LIMITED error. calls the server makes itself are not limited
+	ext.SetRateLimit(a3interface.RateLimit{
+		Scope:     a3interface.RateLimitPerRemoteExecutedOwner,
+		PerSecond: 20,
+		Burst:     50,
+	})
 
+	// INTROSPECTION
+	// "EXTENSION_NAME" callExtension "a3go:commands" lists the registered commands, see also "a3go:version", "a3go:health", "a3go:uptime" and "a3go:errors"
+	ext.RegisterIntrospectionCommands("a3go")
+
+	// BATCHING
+	// "EXTENSION_NAME" callExtension ["batch", [[["test", ["a"]], ["returnJSONFromHashMap", [[["key", "value"]]]]]]] runs several commands in one call
+	ext.RegisterBatchCommand("batch")
+
+	//a3go:example sync
 	// SYNCHRONOUS EXAMPLE
 	// calling "test" as a command will expect a string response to be fed back to Arma.
 	// we don't want to do anything long-running here as it will block Arma. the default "RunInBackground" setting is false, so if we don't configure it, Arma will be waiting for our function returns.
@@ -30,9 +102,15 @@ func init() {
 	testCommand = testCommand.SetFunction(ReceiveTestCommand)
 	// give it something to do when called using "EXTENSION_NAME" callExtension ["test", ["test1", "test2"]]
 	testCommand = testCommand.SetArgsFunction(ReceiveTestCommandArgs)
+	// the description, response and example are written to the manifest, see the "manifest" subcommand in generate.go
+	testCommand = testCommand.SetDescription("Echoes the arguments and the SteamID of the caller.")
+	testCommand = testCommand.SetResponse(`["Called by <steamID>", "test", [args...]]`)
+	testCommand = testCommand.SetExample(`"EXTENSION_NAME" callExtension ["test", ["test1", "test2"]]`)
 	// NOTE: providing no default response will cause the library to return ["Command test called"] to Arma
-	testCommand.Register()
+	ext.Register(testCommand)
+	//a3go:end
 
+	//a3go:example async
 	// ASYNCHRONOUS EXAMPLE
 	// calling testAsync as a command will instead return a default response to Arma and run the function in the background. we can use the a3interface.WriteArmaCallback function to send data back to Arma and the SQF `addMissionEventHandler ["ExtensionCallback", {}]` function to receive it.
 	testAsyncCommand := a3interface.NewRegistration("testAsync")
@@ -40,32 +118,105 @@ func init() {
 	// NOTE: providing no default response will cause the library to return ["Command testAsync called"] to Arma.
 	// a default response is only used when RunInBackground is true, otherwise the functions response return value is sent to Arma.
 	testAsyncCommand = testAsyncCommand.SetDefaultResponse(`["testAsync called"]`)
+	// calls from the same player run one at a time, in the order they were made
+	testAsyncCommand = testAsyncCommand.SetOrderingKey(a3interface.OrderBySteamID())
 	testAsyncCommand = testAsyncCommand.SetFunction(ReceiveTestCommand)
 	testAsyncCommand = testAsyncCommand.SetArgsFunction(ReceiveTestCommandArgs)
-	testAsyncCommand.Register()
+	testAsyncCommand = testAsyncCommand.SetDescription("Echoes the arguments in the background, one call per player at a time.")
+	testAsyncCommand = testAsyncCommand.SetExample(`"EXTENSION_NAME" callExtension ["testAsync", ["test1", "test2"]]`)
+	ext.Register(testAsyncCommand)
+	//a3go:end
 
+	//a3go:example sqlite
 	// CHAIN SYNTAX EXAMPLE
 	// this command will log the caller context to a sqlite database
 	// here we use the API chain syntax to configure the registration
-	a3interface.NewRegistration("saveMyCall").
+	// the argument schema makes sure SaveCallerArgs always receives an array as its first argument. calls that don't match are rejected with a descriptive error before our function is called
+	// the time budget stops a slow database from blocking Arma: after 50ms Arma receives ["pending", jobID] and the result is sent later by the "pending" callback
+	// the access policy stops clients from calling it through remoteExec. on a dedicated server, use ServerOnly to allow only the server itself
+	ext.Register(a3interface.NewRegistration("saveMyCall").
+		SetDescription("Logs the caller's SteamID, server and mission to call_log.db next to the extension.").
+		SetResponse(`["Logged row!", Args: [...], Parsed: [...]]`).
+		SetExample(`"EXTENSION_NAME" callExtension ["saveMyCall", [[1, 2, 3]]]`).
 		SetDefaultResponse(`["saveMyCall called"]`).
 		SetRunInBackground(false).
+		SetAccessPolicy(&a3interface.AccessPolicy{
+			DenyRemoteExecuted: true,
+		}).
+		SetTimeBudget(50 * time.Millisecond).
+		SetArgSchema(a3interface.NewArgSchema().
+			Arg("data", a3interface.ArgArray),
+		).
 		SetFunction(SaveCaller).
-		SetArgsFunction(SaveCallerArgs).
-		Register()
+		SetArgsFunction(SaveCallerArgs),
+	)
+	//a3go:end
 
+	//a3go:example json
 	// JSON EXAMPLE
 	// this command will return a JSON string to Arma from a HashMap
-	a3interface.NewRegistration("returnJSONFromHashMap").
+	ext.Register(a3interface.NewRegistration("returnJSONFromHashMap").
+		SetDescription("Converts a hashmap to indented JSON.").
+		SetResponse("the JSON text").
+		SetExample(`"EXTENSION_NAME" callExtension ["returnJSONFromHashMap", [createHashMapFromArray [["key", "value"]]]]`).
 		SetDefaultResponse(`["returnJSONFromHashMap called"]`).
 		SetRunInBackground(false).
-		SetArgsFunction(ReturnJSONFromHashMapArgs).
-		Register()
+		SetArgSchema(a3interface.NewArgSchema().
+			Arg("hashMap", a3interface.ArgHashMap),
+		).
+		SetArgsFunction(ReturnJSONFromHashMapArgs),
+	)
+	//a3go:end
+}
+
+// subcommands are run by main with the remaining arguments, returning the exit code. generate.go adds the generators when built with the a3gen tag
+var subcommands = map[string]func(args []string) int{
+	"replay": replay,
 }
 
 // NOTE: This main function must exist for building the DLL, but isn't exposed and won't be called by Arma. You could build an exe or binary using this library for testing or other purposes and, upon running it, this main function would be called.
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(subcommand(os.Args[2:]))
+		}
+	}
 	fmt.Println("This is a3go. It is not meant to be run directly. Please see the documentation for more information.")
 	// wait input
 	fmt.Scanln()
 }
+
+// jobIDs matches the random IDs of pending responses and traces, which differ on every run
+var jobIDs = regexp.MustCompile(`[0-9a-f]{16}`)
+
+// replay feeds a recording through the commands of the extension, registered on a fresh Extension so that the replay shares no state with the default one, and prints the differences, returning the exit code
+func replay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := flags.Float64("speed", 0, "replay at the original timing with 1, twice as fast with 2, or without waiting with 0")
+	wait := flags.Duration("wait", a3interface.DefaultReplayCallbackWait, "how long to wait for callbacks after the last call")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("usage: EXTENSION_NAME replay [-speed n] [-wait duration] recording.jsonl")
+		return 2
+	}
+
+	ext := a3interface.NewExtension()
+	ext.SetVersion(version)
+	registerCommands(ext)
+	report, err := ext.ReplayFile(flags.Arg(0), a3interface.ReplayOptions{
+		Speed:        *speed,
+		CallbackWait: *wait,
+		Normalize: func(s string) string {
+			return jobIDs.ReplaceAllString(s, "<id>")
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	report.Write(os.Stdout)
+	if len(report.Diffs) > 0 {
+		return 1
+	}
+	return 0
+}
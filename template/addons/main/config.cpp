@@ -10,12 +10,21 @@ class CfgPatches {
 class CfgFunctions {
 	class a3go {
 		class functions {
-			file = "x\addons\a3go\main\functions";
+			file = "x\a3go\addons\main\functions";
 			class postInit {postInit = 1;};
+			//a3go:example sync
 			class testSync {};
+			//a3go:end
+			//a3go:example async
 			class testAsync {};
+			//a3go:end
+			//a3go:example sqlite
 			class testSaveCaller {};
+			//a3go:end
+			//a3go:example json
 			class hashToJson {};
+			//a3go:end
+			class parseResponse {};
 		};
 	};
 };
\ No newline at end of file